@@ -0,0 +1,82 @@
+package gee
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// mockWriter 是一个不分配内存的 http.ResponseWriter
+type mockWriter struct {
+	header http.Header
+}
+
+func newMockWriter() *mockWriter {
+	return &mockWriter{header: http.Header{}}
+}
+
+func (m *mockWriter) Header() http.Header {
+	return m.header
+}
+
+func (m *mockWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (m *mockWriter) WriteHeader(int) {}
+
+func newBenchEngine() *Engine {
+	r := New()
+	noop := func(c *Context) {}
+	r.Use(noop)
+	for i := 0; i < 200; i++ {
+		v := r.Group(fmt.Sprintf("/api/v%d", i))
+		v.Use(noop)
+		v.GET("/users", noop)
+		v.GET("/users/:id", noop)
+		v.POST("/users/:id/posts/:post", noop)
+		v.GET("/static/*filepath", noop)
+	}
+	r.GET("/", noop)
+	r.GET("/param/:name", func(c *Context) {
+		_ = c.Param("name")
+	})
+	return r
+}
+
+var benchRequests = []struct {
+	name, method, path string
+}{
+	{"Static", http.MethodGet, "/api/v150/users"},
+	{"Param", http.MethodGet, "/api/v150/users/42"},
+	{"TwoParams", http.MethodPost, "/api/v150/users/42/posts/7"},
+	{"CatchAll", http.MethodGet, "/api/v150/static/css/geektutu.css"},
+	{"ParamRead", http.MethodGet, "/param/geektutu"},
+}
+
+func TestServeHTTPZeroAllocs(t *testing.T) {
+	r := newBenchEngine()
+	w := newMockWriter()
+	for _, br := range benchRequests {
+		req, _ := http.NewRequest(br.method, br.path, nil)
+		if allocs := testing.AllocsPerRun(100, func() {
+			r.ServeHTTP(w, req)
+		}); allocs != 0 {
+			t.Fatalf("%s %s: expect 0 allocs, got %v", br.method, br.path, allocs)
+		}
+	}
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	r := newBenchEngine()
+	w := newMockWriter()
+	for _, br := range benchRequests {
+		req, _ := http.NewRequest(br.method, br.path, nil)
+		b.Run(br.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r.ServeHTTP(w, req)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package gee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 请求绑定：把 JSON、表单、查询参数、路由参数和请求头填充到结构体中，填充后按 binding 标签校验
+//
+//	type Login struct {
+//		User string `form:"user" json:"user" binding:"required,min=3"`
+//		Age  int    `form:"age" json:"age" binding:"min=1,max=150"`
+//		Role string `form:"role" json:"role" binding:"omitempty,oneof=admin guest"`
+//	}
+//
+// 支持 string、bool、整数、浮点数、time.Time(time_format 标签指定格式，默认 RFC3339)、
+// time.Duration、指针、切片以及嵌套结构体。
+
+var errNilBody = errors.New("gee: request body is empty")
+
+// Bind 根据请求方法和 Content-Type 选择绑定方式，失败时记录 ErrorTypeBind 错误并以 400 中止请求，
+// 请求体超过 BodyLimit 时以 413 中止，错误响应由 ErrorHandler 统一输出
+func (c *Context) Bind(obj interface{}) error {
+	if err := c.ShouldBind(obj); err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, ErrBodyTooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		c.AbortWithError(code, err).SetType(ErrorTypeBind)
+		return err
+	}
+	return nil
+}
+
+// ShouldBind 根据请求方法和 Content-Type 选择绑定方式，GET 请求和表单请求绑定 form 标签，JSON 请求绑定 json 标签
+func (c *Context) ShouldBind(obj interface{}) error {
+	if c.Method == http.MethodGet {
+		return c.ShouldBindQuery(obj)
+	}
+	switch c.contentType() {
+	case "application/json":
+		return c.ShouldBindJSON(obj)
+	default:
+		return c.ShouldBindForm(obj)
+	}
+}
+
+func (c *Context) ShouldBindJSON(obj interface{}) error {
+	if c.Req.Body == nil || c.Req.Body == http.NoBody {
+		return errNilBody
+	}
+	if err := json.NewDecoder(c.Req.Body).Decode(obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+// ShouldBindForm 绑定 form 标签，数据来自查询参数和 urlencoded/multipart 表单
+func (c *Context) ShouldBindForm(obj interface{}) error {
+	if err := c.Req.ParseMultipartForm(c.engine.MaxMultipartMemory); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+	return bindValues(obj, "form", c.Req.Form, nil)
+}
+
+func (c *Context) ShouldBindQuery(obj interface{}) error {
+	return bindValues(obj, "form", c.Req.URL.Query(), nil)
+}
+
+// ShouldBindUri 绑定 uri 标签，数据来自路由参数
+func (c *Context) ShouldBindUri(obj interface{}) error {
+	values := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		values[p.Key] = append(values[p.Key], p.Value)
+	}
+	return bindValues(obj, "uri", values, nil)
+}
+
+// ShouldBindHeader 绑定 header 标签，标签中的名字不区分大小写
+func (c *Context) ShouldBindHeader(obj interface{}) error {
+	return bindValues(obj, "header", c.Req.Header, textproto.CanonicalMIMEHeaderKey)
+}
+
+func (c *Context) contentType() string {
+	ct := c.Req.Header.Get("Content-Type")
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	return strings.TrimSpace(ct)
+}
+
+// bindValues 把 values 按 tag 填充到 obj 指向的结构体中，canonical 用于规范化标签中的名字
+func bindValues(obj interface{}, tag string, values map[string][]string, canonical func(string) string) error {
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("gee: binding requires a non-nil pointer to struct, got %T", obj)
+	}
+	if err := mapStruct(rv.Elem(), tag, values, canonical); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func mapStruct(rv reflect.Value, tag string, values map[string][]string, canonical func(string) string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue // 未导出字段
+		}
+		name := field.Tag.Get(tag)
+		if name == "-" {
+			continue
+		}
+		if idx := strings.IndexByte(name, ','); idx >= 0 {
+			name = name[:idx]
+		}
+		fv := rv.Field(i)
+		if name == "" && isNestedStruct(field.Type) {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv.Set(reflect.New(field.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if err := mapStruct(fv, tag, values, canonical); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if canonical != nil {
+			name = canonical(name)
+		}
+		vs, ok := values[name]
+		if !ok || len(vs) == 0 {
+			continue
+		}
+		if err := setField(fv, field, vs); err != nil {
+			return fmt.Errorf("gee: cannot bind %q to field %s: %v", strings.Join(vs, ","), field.Name, err)
+		}
+	}
+	return nil
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+func setField(fv reflect.Value, field reflect.StructField, vs []string) error {
+	if fv.Kind() == reflect.Ptr {
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setField(ptr.Elem(), field, vs); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(fv.Type(), len(vs), len(vs))
+		for i, v := range vs {
+			if err := setValue(slice.Index(i), field, v); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setValue(fv, field, vs[0])
+}
+
+func setValue(fv reflect.Value, field reflect.StructField, value string) error {
+	switch fv.Type() {
+	case timeType:
+		if value == "" {
+			return nil
+		}
+		layout := field.Tag.Get("time_format")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		if value == "" {
+			value = "false"
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value == "" {
+			value = "0"
+		}
+		i, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value == "" {
+			value = "0"
+		}
+		u, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		if value == "" {
+			value = "0"
+		}
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		// []byte
+		fv.SetBytes([]byte(value))
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
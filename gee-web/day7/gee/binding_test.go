@@ -0,0 +1,154 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindUser struct {
+	Name    string        `form:"name" json:"name" binding:"required,min=3,max=10"`
+	Age     int           `form:"age" json:"age" binding:"min=1,max=150"`
+	Role    string        `form:"role" json:"role" binding:"omitempty,oneof=admin guest"`
+	Admin   bool          `form:"admin" json:"admin"`
+	Tags    []string      `form:"tag" json:"tags"`
+	Born    time.Time     `form:"born" time_format:"2006-01-02" json:"born"`
+	Timeout time.Duration `form:"timeout" json:"timeout"`
+}
+
+type bindMeta struct {
+	ID    uint   `uri:"id" binding:"required"`
+	Token string `header:"x-token"`
+}
+
+func TestShouldBind(t *testing.T) {
+	r := New()
+	var user bindUser
+	var meta bindMeta
+	var err error
+	bind := func(c *Context) {
+		user, meta = bindUser{}, bindMeta{}
+		if err = c.ShouldBindUri(&meta); err != nil {
+			return
+		}
+		if err = c.ShouldBindHeader(&meta); err != nil {
+			return
+		}
+		err = c.ShouldBind(&user)
+	}
+	r.GET("/users/:id", bind)
+	r.POST("/users/:id", bind)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7?name=geektutu&age=18&role=admin&admin=true&tag=a&tag=b&born=2020-01-09&timeout=3s", nil)
+	req.Header.Set("X-Token", "secret")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	expect := bindUser{
+		Name: "geektutu", Age: 18, Role: "admin", Admin: true, Tags: []string{"a", "b"},
+		Born: time.Date(2020, 1, 9, 0, 0, 0, 0, time.UTC), Timeout: 3 * time.Second,
+	}
+	if err != nil || !reflect.DeepEqual(user, expect) || meta != (bindMeta{ID: 7, Token: "secret"}) {
+		t.Fatalf("unexpected binding result %+v %+v, err: %v", user, meta, err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/users/7", strings.NewReader(`{"name":"gee","age":20,"tags":["x"]}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if err != nil || user.Name != "gee" || user.Age != 20 || !reflect.DeepEqual(user.Tags, []string{"x"}) {
+		t.Fatalf("unexpected json binding result %+v, err: %v", user, err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/users/7", strings.NewReader("name=ge&age=200&role=root"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	verrs, ok := err.(ValidationErrors)
+	if !ok || len(verrs) != 3 {
+		t.Fatalf("expect 3 validation errors, got %v", err)
+	}
+	for i, tag := range []string{"min", "max", "oneof"} {
+		if verrs[i].Tag != tag {
+			t.Fatalf("expect field error on %s, got %v", tag, verrs[i])
+		}
+	}
+
+	// 规则对零值同样生效
+	req = httptest.NewRequest(http.MethodGet, "/users/7?name=geektutu&age=0", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if verrs, ok := err.(ValidationErrors); !ok || len(verrs) != 1 || verrs[0].Field != "bindUser.Age" || verrs[0].Tag != "min" {
+		t.Fatalf("expect min error for age=0, got %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/users/7?age=abc", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if _, ok := err.(ValidationErrors); err == nil || ok {
+		t.Fatalf("expect conversion error, got %v", err)
+	}
+}
+
+func TestBindAbort(t *testing.T) {
+	r := New()
+	r.GET("/users", func(c *Context) {
+		var user struct {
+			Name string `form:"name" binding:"required"`
+		}
+		if c.Bind(&user) != nil {
+			return
+		}
+		c.String(http.StatusOK, user.Name)
+	})
+	if w := performRequest(r, http.MethodGet, "/users"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expect 400, got %d", w.Code)
+	}
+	if w := performRequest(r, http.MethodGet, "/users?name=gee"); w.Code != http.StatusOK || w.Body.String() != "gee" {
+		t.Fatalf("expect 200 'gee', got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestValidateZeroValues(t *testing.T) {
+	type profile struct {
+		Role     string   `binding:"oneof=admin guest"`
+		Nickname string   `binding:"omitempty,min=3"`
+		Level    *int     `binding:"omitempty,max=10"`
+		Score    *int     `binding:"min=0"`
+		Tags     []string `binding:"len=0"`
+	}
+	err := validate(&profile{})
+	verrs, ok := err.(ValidationErrors)
+	if !ok || len(verrs) != 2 || verrs[0].Field != "profile.Role" || verrs[1].Field != "profile.Score" {
+		t.Fatalf("expect errors on Role and Score, got %v", err)
+	}
+
+	zero, eleven := 0, 11
+	if err := validate(&profile{Role: "guest", Score: &zero}); err != nil {
+		t.Fatalf("expect valid profile, got %v", err)
+	}
+	err = validate(&profile{Role: "guest", Nickname: "ge", Level: &eleven, Score: &zero})
+	if verrs, ok := err.(ValidationErrors); !ok || len(verrs) != 2 || verrs[0].Tag != "min" || verrs[1].Tag != "max" {
+		t.Fatalf("omitempty fields should be validated when set, got %v", err)
+	}
+}
+
+func TestValidateInvalidRules(t *testing.T) {
+	type typo struct {
+		Name string `binding:"requird"`
+	}
+	type badParam struct {
+		Age int `binding:"min=abc"`
+	}
+	type outer struct {
+		Inner typo
+	}
+	// 规则写错时第一次校验就 panic，与字段是否为零值无关
+	for _, obj := range []interface{}{&typo{}, &badParam{}, &outer{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expect panic for %T", obj)
+				}
+			}()
+			_ = validate(obj)
+		}()
+	}
+}
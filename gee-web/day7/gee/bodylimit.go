@@ -0,0 +1,57 @@
+package gee
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrBodyTooLarge 在读取的请求体超过 BodyLimit 的限制时返回
+var ErrBodyTooLarge = errors.New("gee: request body too large")
+
+// limitedBody 最多允许读取 remaining 个字节，超出时返回 ErrBodyTooLarge
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.ReadCloser.Read(p)
+	if int64(n) > l.remaining {
+		n = int(l.remaining)
+		l.remaining = 0
+		l.exceeded = true
+		return n, ErrBodyTooLarge
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// BodyLimit 限制请求体最多 n 个字节，可用于全局、分组或单个路由。
+// Content-Length 超过限制时直接返回 413；否则在读取超限时返回 ErrBodyTooLarge，
+// 处理函数没有写响应时由 BodyLimit 返回 413。
+func BodyLimit(n int64) HandlerFunc {
+	return func(c *Context) {
+		if c.Req.ContentLength > n {
+			c.Fail(http.StatusRequestEntityTooLarge, ErrBodyTooLarge.Error())
+			return
+		}
+		if c.Req.Body == nil || c.Req.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		body := &limitedBody{ReadCloser: c.Req.Body, remaining: n}
+		c.Req.Body = body
+		c.Next()
+		if body.exceeded && !c.Writer.Written() {
+			c.Fail(http.StatusRequestEntityTooLarge, ErrBodyTooLarge.Error())
+		}
+	}
+}
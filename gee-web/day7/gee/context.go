@@ -1,9 +1,20 @@
 package gee
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 type H map[string]interface{}
@@ -11,31 +22,90 @@ type H map[string]interface{}
 //上下文类型
 
 type Context struct {
-	Writer http.ResponseWriter
-	Req    *http.Request
+	Writer    ResponseWriter
+	writermem responseWriter
+	Req       *http.Request
 	// request info
 	Path   string
 	Method string
-	Params map[string]string
-	// 状态码
+	Params Params
+	// 状态码，由 Writer 同步更新，与 Writer.Status() 一致，修改它不会影响响应
+	//
+	// Deprecated: 使用 Writer.Status()
 	StatusCode int
+	// 匹配到的路由
+	fullPath string
+	// 处理过程中通过 Error 记录的错误
+	Errors Errors
+	// AbortWithError 设置了状态码但还没有写出响应
+	errorPending bool
 	// middleware
 	handlers []HandlerFunc
 	index    int
 	// engine pointer
 	engine *Engine
+	// 请求范围内的键值对，由中间件写入供后续处理函数读取
+	Keys map[string]interface{}
+	mu   sync.RWMutex
 }
 
-func newContext(w http.ResponseWriter, req *http.Request) *Context {
+// abortIndex 大于任何处理链的长度，index 到达它后 Next 不再执行后续处理函数
+const abortIndex = math.MaxInt32 / 2
+
+// Context 由 Engine 通过 sync.Pool 复用，处理函数返回后不能再持有或使用它
+
+func newContext(engine *Engine) *Context {
 	return &Context{
-		Writer: w,
-		Req:    req,
-		Path:   req.URL.Path,
-		Method: req.Method,
+		Params: make(Params, 0, engine.router.maxParams),
+		engine: engine,
 		index:  -1,
 	}
 }
 
+// Reset 清空上一次请求留下的状态，使 Context 可以处理新的请求
+func (c *Context) Reset(w http.ResponseWriter, req *http.Request) {
+	c.writermem.statusCode = &c.StatusCode
+	c.writermem.reset(w)
+	c.Writer = &c.writermem
+	c.Req = req
+	c.Path = req.URL.Path
+	c.Method = req.Method
+	c.Params = c.Params[:0]
+	c.fullPath = ""
+	c.Errors = c.Errors[:0]
+	c.errorPending = false
+	c.handlers = nil
+	c.index = -1
+	c.Keys = nil
+}
+
+// Copy 返回一个可以在处理函数启动的 goroutine 中安全使用的副本，
+// 副本保留请求信息、参数和键值对，但不能用来写响应，也不能调用 Next
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		Req:      c.Req,
+		Path:     c.Path,
+		Method:   c.Method,
+		fullPath: c.fullPath,
+		engine:   c.engine,
+		index:    abortIndex,
+	}
+	cp.StatusCode = c.Writer.Status()
+	cp.writermem = responseWriter{size: c.Writer.Size(), status: cp.StatusCode, statusCode: &cp.StatusCode}
+	cp.Writer = &cp.writermem
+	cp.Params = make(Params, len(c.Params))
+	copy(cp.Params, c.Params)
+	c.mu.RLock()
+	if c.Keys != nil {
+		cp.Keys = make(map[string]interface{}, len(c.Keys))
+		for k, v := range c.Keys {
+			cp.Keys[k] = v
+		}
+	}
+	c.mu.RUnlock()
+	return cp
+}
+
 func (c *Context) Next() {
 	c.index++
 	s := len(c.handlers)
@@ -44,26 +114,226 @@ func (c *Context) Next() {
 	}
 }
 
+// Abort 阻止执行后续的处理函数，不影响当前处理函数的执行
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+func (c *Context) AbortWithStatus(code int) {
+	c.Status(code)
+	c.Abort()
+}
+
+func (c *Context) AbortWithStatusJSON(code int, obj interface{}) {
+	c.Abort()
+	c.JSON(code, obj)
+}
+
 func (c *Context) Fail(code int, err string) {
-	c.index = len(c.handlers)
-	c.JSON(code, H{"message": err})
+	c.AbortWithStatusJSON(code, H{"message": err})
+}
+
+// Set 保存一个请求范围内的键值对
+func (c *Context) Set(key string, value interface{}) {
+	c.mu.Lock()
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	c.Keys[key] = value
+	c.mu.Unlock()
+}
+
+func (c *Context) Get(key string) (value interface{}, exists bool) {
+	c.mu.RLock()
+	value, exists = c.Keys[key]
+	c.mu.RUnlock()
+	return
+}
+
+// MustGet 同 Get，key 不存在时 panic
+func (c *Context) MustGet(key string) interface{} {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("key \"" + key + "\" does not exist")
+}
+
+func (c *Context) GetString(key string) (s string) {
+	if value, ok := c.Get(key); ok && value != nil {
+		s, _ = value.(string)
+	}
+	return
+}
+
+func (c *Context) GetBool(key string) (b bool) {
+	if value, ok := c.Get(key); ok && value != nil {
+		b, _ = value.(bool)
+	}
+	return
+}
+
+func (c *Context) GetInt(key string) (i int) {
+	if value, ok := c.Get(key); ok && value != nil {
+		i, _ = value.(int)
+	}
+	return
+}
+
+func (c *Context) GetInt64(key string) (i int64) {
+	if value, ok := c.Get(key); ok && value != nil {
+		i, _ = value.(int64)
+	}
+	return
+}
+
+func (c *Context) GetUint(key string) (u uint) {
+	if value, ok := c.Get(key); ok && value != nil {
+		u, _ = value.(uint)
+	}
+	return
+}
+
+func (c *Context) GetFloat64(key string) (f float64) {
+	if value, ok := c.Get(key); ok && value != nil {
+		f, _ = value.(float64)
+	}
+	return
+}
+
+func (c *Context) GetTime(key string) (t time.Time) {
+	if value, ok := c.Get(key); ok && value != nil {
+		t, _ = value.(time.Time)
+	}
+	return
+}
+
+func (c *Context) GetDuration(key string) (d time.Duration) {
+	if value, ok := c.Get(key); ok && value != nil {
+		d, _ = value.(time.Duration)
+	}
+	return
+}
+
+func (c *Context) GetStringSlice(key string) (ss []string) {
+	if value, ok := c.Get(key); ok && value != nil {
+		ss, _ = value.([]string)
+	}
+	return
+}
+
+func (c *Context) GetStringMap(key string) (sm map[string]interface{}) {
+	if value, ok := c.Get(key); ok && value != nil {
+		sm, _ = value.(map[string]interface{})
+	}
+	return
 }
 
 func (c *Context) PostForm(key string) string {
 	return c.Req.FormValue(key)
 }
 
+// FormFile 返回 multipart 表单中名为 name 的第一个文件
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if c.Req.MultipartForm == nil {
+		if err := c.Req.ParseMultipartForm(c.engine.MaxMultipartMemory); err != nil {
+			return nil, err
+		}
+	}
+	f, fh, err := c.Req.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Close()
+	return fh, nil
+}
+
+// MultipartForm 解析并返回 multipart 表单，超过 Engine.MaxMultipartMemory 的部分保存在临时文件中
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	err := c.Req.ParseMultipartForm(c.engine.MaxMultipartMemory)
+	return c.Req.MultipartForm, err
+}
+
+// SaveUploadedFile 把上传的文件保存到 dst，dst 所在的目录不存在时会被创建
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// FullPath 返回匹配到的路由，如 "/hello/:name"，未匹配到路由时返回空字符串
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 func (c *Context) Param(key string) string {
-	value, _ := c.Params[key]
-	return value
+	return c.Params.ByName(key)
+}
+
+// ParamInt 把路由参数 key 解析为 int，通常与 :key<int> 约束一起使用
+func (c *Context) ParamInt(key string) (int, error) {
+	value, ok := c.Params.Get(key)
+	if !ok {
+		return 0, fmt.Errorf("gee: route parameter %q does not exist", key)
+	}
+	return strconv.Atoi(value)
+}
+
+// ParamUint 把路由参数 key 解析为 uint，通常与 :key<uint> 约束一起使用
+func (c *Context) ParamUint(key string) (uint, error) {
+	value, ok := c.Params.Get(key)
+	if !ok {
+		return 0, fmt.Errorf("gee: route parameter %q does not exist", key)
+	}
+	u, err := strconv.ParseUint(value, 10, strconv.IntSize)
+	return uint(u), err
 }
 
 func (c *Context) Query(key string) string {
 	return c.Req.URL.Query().Get(key)
 }
 
+// ClientIP 返回客户端 IP，Engine.ForwardedByClientIP 为 true 时优先使用 X-Forwarded-For 和 X-Real-Ip
+func (c *Context) ClientIP() string {
+	if c.engine.ForwardedByClientIP {
+		if forwarded := c.Req.Header.Get("X-Forwarded-For"); forwarded != "" {
+			if i := strings.IndexByte(forwarded, ','); i >= 0 {
+				forwarded = forwarded[:i]
+			}
+			if ip := strings.TrimSpace(forwarded); ip != "" {
+				return ip
+			}
+		}
+		if ip := strings.TrimSpace(c.Req.Header.Get("X-Real-Ip")); ip != "" {
+			return ip
+		}
+	}
+	if ip, _, err := net.SplitHostPort(strings.TrimSpace(c.Req.RemoteAddr)); err == nil {
+		return ip
+	}
+	return c.Req.RemoteAddr
+}
+
+// Status 设置响应的状态码，响应头在写出响应体或请求结束时才会写出
 func (c *Context) Status(code int) {
-	c.StatusCode = code
+	c.errorPending = false
 	c.Writer.WriteHeader(code)
 }
 
@@ -71,30 +341,105 @@ func (c *Context) SetHeader(key string, value string) {
 	c.Writer.Header().Set(key, value)
 }
 
-func (c *Context) String(code int, format string, values ...interface{}) {
-	c.SetHeader("Content-Type", "text/plain")
+// Render 以状态码 code 输出 r，r 编码失败且尚未写出内容时改为返回 500
+func (c *Context) Render(code int, r Render) {
 	c.Status(code)
-	_, _ = c.Writer.Write([]byte(fmt.Sprintf(format, values...)))
+	r.WriteContentType(c.Writer)
+	if !bodyAllowedForStatus(code) {
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	if err := r.Render(c.Writer); err != nil {
+		if c.Writer.Written() {
+			logrus.Errorf("render error after response was written: %v", err)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		http.Error(c.Writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (c *Context) String(code int, format string, values ...interface{}) {
+	c.Render(code, String{Format: format, Data: values})
 }
 
 func (c *Context) JSON(code int, obj interface{}) {
-	c.SetHeader("Content-Type", "application/json")
-	c.Status(code)
-	encoder := json.NewEncoder(c.Writer)
-	if err := encoder.Encode(obj); err != nil {
-		http.Error(c.Writer, err.Error(), 500)
-	}
+	c.Render(code, JSON{Data: obj})
+}
+
+func (c *Context) IndentedJSON(code int, obj interface{}) {
+	c.Render(code, IndentedJSON{Data: obj})
+}
+
+// SecureJSON 在 JSON 数组前加上 Engine.SecureJSONPrefix
+func (c *Context) SecureJSON(code int, obj interface{}) {
+	c.Render(code, SecureJSON{Prefix: c.engine.SecureJSONPrefix, Data: obj})
+}
+
+// JSONP 使用查询参数 callback 作为回调函数名
+func (c *Context) JSONP(code int, obj interface{}) {
+	c.Render(code, JSONP{Callback: c.Query("callback"), Data: obj})
+}
+
+func (c *Context) XML(code int, obj interface{}) {
+	c.Render(code, XML{Data: obj})
 }
 
 func (c *Context) Data(code int, data []byte) {
-	c.Status(code)
-	_, _ = c.Writer.Write(data)
+	c.Render(code, Data{Data: data})
+}
+
+// DataFromReader 把 reader 的内容写入响应，extraHeaders 为额外的响应头
+func (c *Context) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, extraHeaders map[string]string) {
+	c.Render(code, Reader{
+		ContentType:   contentType,
+		ContentLength: contentLength,
+		Reader:        reader,
+		Headers:       extraHeaders,
+	})
+}
+
+func (c *Context) Redirect(code int, location string) {
+	c.Render(code, Redirect{Code: code, Request: c.Req, Location: location})
 }
 
 func (c *Context) HTML(code int, name string, data interface{}) {
-	c.SetHeader("Content-Type", "text/html")
-	c.Status(code)
-	if err := c.engine.htmlTemplates.ExecuteTemplate(c.Writer, name, data); err != nil {
-		c.Fail(500, err.Error())
+	var r Render = HTML{Name: name, Data: data}
+	if c.engine.HTMLRender != nil {
+		r = c.engine.HTMLRender.Instance(name, data)
 	}
+	c.Render(code, r)
+}
+
+// Stream 反复调用 step 向响应写入数据，每次调用后立即 flush。
+// step 返回 false 或客户端断开连接时结束，返回值表示客户端是否已经断开。
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	done := c.Req.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+			keepOpen := step(c.Writer)
+			c.flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
+
+// SSEvent 写入一条名为 name 的 Server-Sent Events 消息并立即 flush，通常在 Stream 中调用
+func (c *Context) SSEvent(name string, data interface{}) {
+	r := SSEvent{Event: name, Data: data}
+	r.WriteContentType(c.Writer)
+	if err := r.Render(c.Writer); err != nil {
+		logrus.Errorf("render server-sent event error: %v", err)
+		return
+	}
+	c.flush()
+}
+
+func (c *Context) flush() {
+	c.Writer.Flush()
 }
@@ -0,0 +1,136 @@
+package gee
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAbortAndKeys(t *testing.T) {
+	r := New()
+	handled := false
+	auth := func(c *Context) {
+		if c.Query("user") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("user", c.Query("user"))
+		c.Set("uid", 42)
+	}
+	r.GET("/me", auth, func(c *Context) {
+		handled = true
+		cp := c.Copy()
+		done := make(chan string)
+		go func() {
+			done <- cp.GetString("user")
+		}()
+		c.String(http.StatusOK, "%s %d", <-done, c.GetInt("uid"))
+	})
+
+	w := performRequest(r, http.MethodGet, "/me")
+	if w.Code != http.StatusUnauthorized || handled {
+		t.Fatalf("expect 401 and aborted chain, got %d handled=%t", w.Code, handled)
+	}
+
+	w = performRequest(r, http.MethodGet, "/me?user=geektutu")
+	if w.Code != http.StatusOK || w.Body.String() != "geektutu 42" {
+		t.Fatalf("expect 200 'geektutu 42', got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestMustGet(t *testing.T) {
+	c := &Context{}
+	c.Set("key", "value")
+	if c.MustGet("key") != "value" || c.GetInt("key") != 0 {
+		t.Fatal("unexpected value for key")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustGet should panic for missing key")
+		}
+	}()
+	c.MustGet("missing")
+}
+
+func newUploadRequest(t *testing.T, field, filename, content string) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.WriteString(fw, content)
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestUploadFile(t *testing.T) {
+	dir := t.TempDir()
+	r := New()
+	r.POST("/upload", BodyLimit(1024), func(c *Context) {
+		file, err := c.FormFile("file")
+		if errors.Is(err, ErrBodyTooLarge) {
+			return // 由 BodyLimit 返回 413
+		}
+		if err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := c.SaveUploadedFile(file, filepath.Join(dir, "sub", file.Filename)); err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "%s uploaded", file.Filename)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(t, "file", "doc.txt", "hello gee"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expect 200, got %d %q", w.Code, w.Body.String())
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "sub", "doc.txt")); err != nil || string(data) != "hello gee" {
+		t.Fatalf("unexpected saved file %q, err: %v", data, err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(t, "file", "big.txt", strings.Repeat("x", 2048)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expect 413 for large Content-Length, got %d", w.Code)
+	}
+
+	// 未知长度的请求体在读取时超限
+	req := newUploadRequest(t, "file", "big.txt", strings.Repeat("x", 2048))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expect 413 for large chunked body, got %d", w.Code)
+	}
+}
+
+func TestBodyLimitBind(t *testing.T) {
+	r := New()
+	r.POST("/json", BodyLimit(8), func(c *Context) {
+		var obj H
+		if c.Bind(&obj) == nil {
+			c.JSON(http.StatusOK, obj)
+		}
+	})
+	req := httptest.NewRequest(http.MethodPost, "/json", strings.NewReader(`{"name":"geektutu"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expect 413, got %d", w.Code)
+	}
+}
@@ -0,0 +1,168 @@
+package gee
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrNoCookieKeys  = errors.New("gee: cookie keys are not set, call Engine.SetCookieKeys first")
+	ErrInvalidCookie = errors.New("gee: invalid cookie value")
+)
+
+// CookieOptions 是 SetCookie 的可选属性，Path 为空时使用 "/"
+type CookieOptions struct {
+	Path     string
+	Domain   string
+	MaxAge   int // 0 表示会话 cookie，小于 0 表示立即删除
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// SetCookieKeys 设置签名和加密 cookie 使用的密钥。
+// 第一个密钥用于签名和加密，所有密钥都可以用于校验和解密，轮换密钥时把新密钥放在最前面即可。
+func (engine *Engine) SetCookieKeys(keys ...[]byte) {
+	engine.cookieKeys = keys
+}
+
+// Cookie 返回名为 name 的 cookie 解码后的值
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryUnescape(cookie.Value)
+}
+
+// SetCookie 设置 cookie，value 会被 URL 编码
+func (c *Context) SetCookie(name, value string, opts CookieOptions) {
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		MaxAge:   opts.MaxAge,
+		Secure:   opts.Secure,
+		HttpOnly: opts.HttpOnly,
+		SameSite: opts.SameSite,
+	})
+}
+
+// SetSignedCookie 设置带 HMAC-SHA256 签名的 cookie，客户端可以读取但无法篡改它的值
+func (c *Context) SetSignedCookie(name, value string, opts CookieOptions) error {
+	keys := c.engine.cookieKeys
+	if len(keys) == 0 {
+		return ErrNoCookieKeys
+	}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(value))
+	c.SetCookie(name, payload+"."+signCookie(keys[0], name, payload), opts)
+	return nil
+}
+
+// SignedCookie 校验并返回 SetSignedCookie 设置的 cookie 的值，签名不匹配时返回 ErrInvalidCookie
+func (c *Context) SignedCookie(name string) (string, error) {
+	keys := c.engine.cookieKeys
+	if len(keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	raw, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	i := strings.LastIndexByte(raw, '.')
+	if i < 0 {
+		return "", ErrInvalidCookie
+	}
+	payload, signature := raw[:i], raw[i+1:]
+	for _, key := range keys {
+		if hmac.Equal([]byte(signature), []byte(signCookie(key, name, payload))) {
+			value, err := base64.RawURLEncoding.DecodeString(payload)
+			if err != nil {
+				return "", ErrInvalidCookie
+			}
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// SetEncryptedCookie 设置以 AES-GCM 加密的 cookie，客户端既不能读取也不能篡改它的值
+func (c *Context) SetEncryptedCookie(name, value string, opts CookieOptions) error {
+	keys := c.engine.cookieKeys
+	if len(keys) == 0 {
+		return ErrNoCookieKeys
+	}
+	aead, err := cookieCipher(keys[0])
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	c.SetCookie(name, base64.RawURLEncoding.EncodeToString(sealed), opts)
+	return nil
+}
+
+// EncryptedCookie 解密并返回 SetEncryptedCookie 设置的 cookie 的值，无法解密时返回 ErrInvalidCookie
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	keys := c.engine.cookieKeys
+	if len(keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	raw, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, key := range keys {
+		aead, err := cookieCipher(key)
+		if err != nil {
+			return "", err
+		}
+		if len(sealed) < aead.NonceSize() {
+			return "", ErrInvalidCookie
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if value, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// signCookie 对 name 和 payload 一起签名，防止把一个 cookie 的值挪用到另一个 cookie
+func signCookie(key []byte, name, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cookieCipher 由任意长度的密钥派生出 AES-256 密钥，签名和加密不直接共用同一个密钥
+func cookieCipher(key []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("gee cookie encryption"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,73 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newCookieEngine(keys ...[]byte) *Engine {
+	r := New()
+	r.SetCookieKeys(keys...)
+	r.GET("/set", func(c *Context) {
+		opts := CookieOptions{HttpOnly: true, SameSite: http.SameSiteLaxMode}
+		c.SetCookie("plain", "hello gee", opts)
+		_ = c.SetSignedCookie("signed", "uid=42", opts)
+		_ = c.SetEncryptedCookie("secret", "token", opts)
+	})
+	r.GET("/get", func(c *Context) {
+		plain, _ := c.Cookie("plain")
+		signed, err1 := c.SignedCookie("signed")
+		secret, err2 := c.EncryptedCookie("secret")
+		if err1 != nil || err2 != nil {
+			c.String(http.StatusForbidden, "%v %v", err1, err2)
+			return
+		}
+		c.String(http.StatusOK, "%s|%s|%s", plain, signed, secret)
+	})
+	return r
+}
+
+func replayCookies(r *Engine, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/get", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCookies(t *testing.T) {
+	oldKey, newKey := []byte("old secret"), []byte("new secret")
+	r := newCookieEngine(oldKey)
+	cookies := performRequest(r, http.MethodGet, "/set").Result().Cookies()
+	if len(cookies) != 3 || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode || cookies[0].Path != "/" {
+		t.Fatalf("unexpected cookies %v", cookies)
+	}
+	if strings.Contains(cookies[2].Value, "token") {
+		t.Fatalf("encrypted cookie shouldn't contain plain text: %s", cookies[2].Value)
+	}
+
+	if w := replayCookies(r, cookies); w.Body.String() != "hello gee|uid=42|token" {
+		t.Fatalf("unexpected cookie values %q", w.Body.String())
+	}
+
+	// 轮换密钥后旧 cookie 仍然有效
+	r.SetCookieKeys(newKey, oldKey)
+	if w := replayCookies(r, cookies); w.Code != http.StatusOK {
+		t.Fatalf("cookies signed by old key should still be accepted, got %q", w.Body.String())
+	}
+	r.SetCookieKeys(newKey)
+	if w := replayCookies(r, cookies); w.Code != http.StatusForbidden {
+		t.Fatalf("cookies signed by removed key should be rejected, got %d", w.Code)
+	}
+
+	tampered := *cookies[1]
+	tampered.Value = "x" + tampered.Value
+	r.SetCookieKeys(oldKey)
+	if w := replayCookies(r, []*http.Cookie{cookies[0], &tampered, cookies[2]}); w.Code != http.StatusForbidden {
+		t.Fatalf("tampered cookie should be rejected, got %d", w.Code)
+	}
+}
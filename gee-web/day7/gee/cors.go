@@ -0,0 +1,122 @@
+package gee
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig 是 CORS 中间件的配置
+type CORSConfig struct {
+	// 允许的来源，"*" 表示任意来源，也可以包含一个通配符，如 "https://*.example.com"
+	AllowOrigins []string
+	// 自定义的来源校验，返回 true 表示允许，与 AllowOrigins 任一匹配即可
+	AllowOriginFunc func(origin string) bool
+	// 预检请求允许的方法，为空时允许 GET、POST、PUT、PATCH、DELETE、HEAD
+	AllowMethods []string
+	// 预检请求允许的请求头，为空时允许预检请求中 Access-Control-Request-Headers 列出的所有请求头
+	AllowHeaders []string
+	// 允许浏览器读取的响应头
+	ExposeHeaders []string
+	// 是否允许携带 cookie 等凭证，不能与 AllowOrigins 中的 "*" 同时使用，
+	// 确实需要允许任意来源携带凭证时，应通过 AllowOriginFunc 明确地校验来源
+	AllowCredentials bool
+	// 预检结果的缓存时间
+	MaxAge time.Duration
+}
+
+// CORS 返回处理跨域请求的中间件，预检请求由中间件直接以 204 响应。
+// 未注册 OPTIONS 路由的路径，预检请求只会经过 engine 上的全局中间件，
+// 因此 CORS 通常通过 engine.Use 注册；只用于分组时需要为分组注册 OPTIONS 路由，如 group.OPTIONS("/*path", ...)。
+func CORS(config CORSConfig) HandlerFunc {
+	allowAll := false
+	exact := make(map[string]bool)
+	var wildcards [][2]string
+	for _, origin := range config.AllowOrigins {
+		switch i := strings.IndexByte(origin, '*'); {
+		case origin == "*":
+			if config.AllowCredentials {
+				panic("gee: CORS AllowOrigins \"*\" can not be used with AllowCredentials, use AllowOriginFunc instead")
+			}
+			allowAll = true
+		case i >= 0:
+			wildcards = append(wildcards, [2]string{origin[:i], origin[i+1:]})
+		default:
+			exact[origin] = true
+		}
+	}
+	allowed := func(origin string) bool {
+		if allowAll || exact[origin] {
+			return true
+		}
+		for _, w := range wildcards {
+			if len(origin) > len(w[0])+len(w[1]) && strings.HasPrefix(origin, w[0]) && strings.HasSuffix(origin, w[1]) {
+				return true
+			}
+		}
+		return config.AllowOriginFunc != nil && config.AllowOriginFunc(origin)
+	}
+
+	methods := config.AllowMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead}
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(config.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := ""
+	if config.MaxAge > 0 {
+		maxAge = strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+	}
+
+	return func(c *Context) {
+		origin := c.Req.Header.Get("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		preflight := c.Method == http.MethodOptions && c.Req.Header.Get("Access-Control-Request-Method") != ""
+		if !allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// 不设置 CORS 响应头，由浏览器拒绝跨域访问
+			c.Next()
+			return
+		}
+
+		if allowAll {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if requested := c.Req.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		if maxAge != "" {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
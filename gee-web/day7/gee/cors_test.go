@@ -0,0 +1,98 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func corsRequest(r *Engine, method, path, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Origin", origin)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS(t *testing.T) {
+	r := New()
+	r.Use(CORS(CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.geektutu.com"},
+		AllowOriginFunc:  func(origin string) bool { return strings.HasSuffix(origin, ".local") },
+		AllowMethods:     []string{http.MethodGet, http.MethodPut},
+		ExposeHeaders:    []string{"X-Total"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}))
+	r.GET("/api/items", func(c *Context) {
+		c.String(http.StatusOK, "items")
+	})
+
+	preflight := map[string]string{
+		"Access-Control-Request-Method":  http.MethodPut,
+		"Access-Control-Request-Headers": "X-Token",
+	}
+	for _, path := range []string{"/api/items", "/api/unknown"} {
+		w := corsRequest(r, http.MethodOptions, path, "https://app.example.com", preflight)
+		h := w.Header()
+		if w.Code != http.StatusNoContent || h.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+			h.Get("Access-Control-Allow-Methods") != "GET, PUT" || h.Get("Access-Control-Allow-Headers") != "X-Token" ||
+			h.Get("Access-Control-Max-Age") != "3600" || h.Get("Access-Control-Allow-Credentials") != "true" {
+			t.Fatalf("%s: unexpected preflight response %d %v", path, w.Code, h)
+		}
+	}
+
+	for _, origin := range []string{"https://a.geektutu.com", "http://dev.local"} {
+		w := corsRequest(r, http.MethodGet, "/api/items", origin, nil)
+		if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != origin || w.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+			t.Fatalf("%s: unexpected response %d %v", origin, w.Code, w.Header())
+		}
+	}
+
+	w := corsRequest(r, http.MethodGet, "/api/items", "https://evil.com", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("disallowed origin shouldn't get CORS headers, got %v", w.Header())
+	}
+	if w = corsRequest(r, http.MethodOptions, "/api/items", "https://evil.com", preflight); w.Code != http.StatusForbidden {
+		t.Fatalf("expect 403 for disallowed preflight, got %d", w.Code)
+	}
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	r := New()
+	r.Use(CORS(CORSConfig{AllowOrigins: []string{"*"}}))
+	r.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	w := corsRequest(r, http.MethodGet, "/", "https://any.com", nil)
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("expect wildcard origin, got %v", w.Header())
+	}
+}
+
+func TestCORSWildcardCredentials(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("\"*\" with AllowCredentials should panic")
+			}
+		}()
+		CORS(CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+	}()
+
+	// 通过 AllowOriginFunc 明确地允许任意来源携带凭证
+	r := New()
+	r.Use(CORS(CORSConfig{AllowOriginFunc: func(string) bool { return true }, AllowCredentials: true}))
+	r.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	w := corsRequest(r, http.MethodGet, "/", "https://any.com", nil)
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://any.com" || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("expect echoed origin with credentials, got %v", w.Header())
+	}
+}
@@ -0,0 +1,82 @@
+package gee
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RouteInfo 描述一条已注册的路由，Middlewares 按执行顺序列出全局、分组和路由自身的中间件
+type RouteInfo struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Name        string   `json:"name,omitempty"`
+	Handler     string   `json:"handler"`
+	Middlewares []string `json:"middlewares"`
+}
+
+// Routes 按注册顺序返回所有路由
+func (engine *Engine) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(engine.routes))
+	for _, r := range engine.routes {
+		handlers := r.nodes[0].handlers
+		last := len(handlers) - 1
+		middlewares := make([]string, last)
+		for i, h := range handlers[:last] {
+			middlewares[i] = nameOfFunction(h)
+		}
+		routes = append(routes, RouteInfo{
+			Method:      r.method,
+			Path:        r.pattern,
+			Name:        r.name,
+			Handler:     nameOfFunction(handlers[last]),
+			Middlewares: middlewares,
+		})
+	}
+	return routes
+}
+
+func nameOfFunction(f interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+// DebugRoutes 返回以 JSON 输出路由表的处理函数，供运维查看，例如：
+//
+//	r.GET("/debug/routes", gee.DebugRoutes())
+//
+// 路由表会暴露内部实现，对外提供服务时应当加上鉴权中间件
+func DebugRoutes() HandlerFunc {
+	return func(c *Context) {
+		c.JSON(http.StatusOK, c.engine.Routes())
+	}
+}
+
+// writeRoutes 以表格的形式输出路由表
+func (engine *Engine) writeRoutes(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tHANDLER\tMIDDLEWARES")
+	for _, r := range engine.Routes() {
+		name := r.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", r.Method, r.Path, name, r.Handler, len(r.Middlewares))
+	}
+	tw.Flush()
+}
+
+// debugPrintRoutes 在 DebugMode 下启动服务时打印路由表
+func (engine *Engine) debugPrintRoutes(addr string) {
+	if !IsDebugging() {
+		return
+	}
+	var buf strings.Builder
+	engine.writeRoutes(&buf)
+	logrus.Infof("Listening on %s with %d routes\n%s", addr, len(engine.routes), buf.String())
+}
@@ -0,0 +1,83 @@
+package gee
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func authMiddleware(c *Context) { c.Next() }
+
+func showUser(c *Context) {}
+
+func TestRoutes(t *testing.T) {
+	r := New()
+	r.Use(Recovery())
+	v1 := r.Group("/v1")
+	v1.Use(authMiddleware)
+	v1.GET("/users/:id", showUser).Name("user.show")
+	r.POST("/login", func(c *Context) {})
+
+	routes := r.Routes()
+	want := []RouteInfo{
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/users/:id",
+			Name:        "user.show",
+			Handler:     "Gee/gee-web/day7/gee.showUser",
+			Middlewares: []string{"Gee/gee-web/day7/gee.Recovery.func1", "Gee/gee-web/day7/gee.authMiddleware"},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/login",
+			Handler:     "Gee/gee-web/day7/gee.TestRoutes.func1",
+			Middlewares: []string{"Gee/gee-web/day7/gee.Recovery.func1"},
+		},
+	}
+	if !reflect.DeepEqual(routes, want) {
+		t.Fatalf("unexpected routes:\n%+v\nwant:\n%+v", routes, want)
+	}
+
+	var buf strings.Builder
+	r.writeRoutes(&buf)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "METHOD") ||
+		!strings.Contains(lines[1], "user.show") || !strings.HasSuffix(lines[2], "1") {
+		t.Fatalf("unexpected route table:\n%s", buf.String())
+	}
+}
+
+func TestDebugRoutes(t *testing.T) {
+	r := New()
+	r.GET("/debug/routes", DebugRoutes())
+	r.GET("/hello", showUser)
+
+	w := performRequest(r, http.MethodGet, "/debug/routes")
+	var routes []RouteInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || routes[1].Path != "/hello" || routes[1].Handler != "Gee/gee-web/day7/gee.showUser" {
+		t.Fatalf("unexpected routes: %+v", routes)
+	}
+}
+
+func TestSetMode(t *testing.T) {
+	defer SetMode(Mode())
+	SetMode("")
+	if !IsDebugging() {
+		t.Fatal("default mode should be debug")
+	}
+	SetMode(ReleaseMode)
+	if Mode() != ReleaseMode || IsDebugging() {
+		t.Fatalf("expect release mode, got %s", Mode())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("unknown mode should panic")
+		}
+	}()
+	SetMode("production")
+}
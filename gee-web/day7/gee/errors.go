@@ -0,0 +1,167 @@
+package gee
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrorType 区分错误能否展示给客户端，可以按位组合
+type ErrorType uint8
+
+const (
+	// ErrorTypePrivate 只记录日志，响应中使用状态码对应的通用文本
+	ErrorTypePrivate ErrorType = 1 << iota
+	// ErrorTypePublic 错误信息可以直接返回给客户端
+	ErrorTypePublic
+	// ErrorTypeBind 请求绑定或校验失败，错误信息可以直接返回给客户端
+	ErrorTypeBind
+	// ErrorTypeAny 匹配所有类型
+	ErrorTypeAny ErrorType = 1<<8 - 1
+)
+
+// Error 是通过 Context.Error 记录的错误，Meta 可以附带任意数据供错误处理中间件使用
+type Error struct {
+	Err  error
+	Type ErrorType
+	Meta interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) SetType(t ErrorType) *Error {
+	e.Type = t
+	return e
+}
+
+func (e *Error) SetMeta(meta interface{}) *Error {
+	e.Meta = meta
+	return e
+}
+
+func (e *Error) IsType(t ErrorType) bool {
+	return e.Type&t != 0
+}
+
+// IsPublic 判断错误信息能否返回给客户端
+func (e *Error) IsPublic() bool {
+	return e.IsType(ErrorTypePublic | ErrorTypeBind)
+}
+
+// Errors 按记录的顺序保存一个请求中的错误
+type Errors []*Error
+
+// ByType 返回类型与 t 匹配的错误
+func (errs Errors) ByType(t ErrorType) Errors {
+	if t == ErrorTypeAny {
+		return errs
+	}
+	var result Errors
+	for _, e := range errs {
+		if e.IsType(t) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Last 返回最后一个错误，没有错误时返回 nil
+func (errs Errors) Last() *Error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs[len(errs)-1]
+}
+
+func (errs Errors) String() string {
+	var buf strings.Builder
+	for i, e := range errs {
+		fmt.Fprintf(&buf, "Error #%02d: %s\n", i+1, e.Err)
+		if e.Meta != nil {
+			fmt.Fprintf(&buf, "     Meta: %v\n", e.Meta)
+		}
+	}
+	return buf.String()
+}
+
+// Error 记录一个错误并返回它，err 为 *Error 时保留其类型，否则记为 ErrorTypePrivate。
+// Error 不会写响应，也不会中止请求，通常配合 ErrorHandler 使用
+func (c *Context) Error(err error) *Error {
+	if err == nil {
+		panic("gee: Context.Error called with a nil error")
+	}
+	e, ok := err.(*Error)
+	if !ok {
+		e = &Error{Err: err, Type: ErrorTypePrivate}
+	}
+	c.Errors = append(c.Errors, e)
+	return e
+}
+
+// AbortWithError 记录错误并以状态码 code 中止请求，但不立即写响应：
+// 响应由 ErrorHandler 输出，没有使用 ErrorHandler 时在请求结束时以默认格式输出
+func (c *Context) AbortWithError(code int, err error) *Error {
+	e := c.Error(err)
+	c.Abort()
+	c.Writer.WriteHeader(code)
+	c.errorPending = true
+	return e
+}
+
+// ErrorHandlerConfig 是 ErrorHandlerWithConfig 的配置
+type ErrorHandlerConfig struct {
+	// 输出错误响应，默认输出 {"message": ...}，私有错误的信息会被替换为状态码对应的文本
+	Render func(c *Context, code int, errs Errors)
+}
+
+// ErrorHandler 在后续处理函数返回后统一输出 c.Errors 中的错误
+func ErrorHandler() HandlerFunc {
+	return ErrorHandlerWithConfig(ErrorHandlerConfig{})
+}
+
+// ErrorHandlerWithConfig 返回自定义输出格式的 ErrorHandler，只有还没有写出响应时才会输出。
+// 状态码取 AbortWithError 设置的值，否则绑定错误为 400，其他为 500。私有错误总是写入日志。
+func ErrorHandlerWithConfig(config ErrorHandlerConfig) HandlerFunc {
+	render := config.Render
+	if render == nil {
+		render = renderErrors
+	}
+	return func(c *Context) {
+		c.Next()
+		if len(c.Errors) == 0 {
+			return
+		}
+		for _, e := range c.Errors.ByType(ErrorTypePrivate) {
+			logrus.Errorf("%s %s: %v", c.Method, c.Path, e.Err)
+		}
+		if c.Writer.Written() {
+			return
+		}
+		code := c.Writer.Status()
+		if !c.errorPending {
+			code = http.StatusInternalServerError
+			if c.Errors.Last().IsType(ErrorTypeBind) {
+				code = http.StatusBadRequest
+			}
+		}
+		c.errorPending = false
+		render(c, code, c.Errors)
+	}
+}
+
+// renderErrors 是默认的错误输出格式，与 Context.Fail 一致
+func renderErrors(c *Context, code int, errs Errors) {
+	message := http.StatusText(code)
+	if e := errs.Last(); e.IsPublic() {
+		message = e.Error()
+	}
+	c.JSON(code, H{"message": message})
+}
@@ -0,0 +1,137 @@
+package gee
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNoRouteAndNoMethod(t *testing.T) {
+	r := New()
+	var trace []string
+	r.Use(func(c *Context) {
+		trace = append(trace, "global")
+		c.Next()
+	})
+	r.GET("/hello", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	r.NoRoute(func(c *Context) {
+		c.JSON(http.StatusNotFound, H{"message": "no route " + c.Path})
+	})
+	r.NoMethod(func(c *Context) {
+		c.JSON(http.StatusMethodNotAllowed, H{"message": "no method " + c.Method})
+	})
+
+	w := performRequest(r, http.MethodGet, "/world")
+	if w.Code != http.StatusNotFound || w.Body.String() != "{\"message\":\"no route /world\"}\n" {
+		t.Fatalf("expect custom 404, got %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(r, http.MethodPost, "/hello")
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD, OPTIONS" ||
+		w.Body.String() != "{\"message\":\"no method POST\"}\n" {
+		t.Fatalf("expect custom 405 with Allow header, got %d %q %q", w.Code, w.Header().Get("Allow"), w.Body.String())
+	}
+	if len(trace) != 2 {
+		t.Fatalf("global middleware should run for unmatched requests, got %v", trace)
+	}
+
+	// 在 NoRoute 之后添加的全局中间件同样生效
+	r.Use(func(c *Context) {
+		c.SetHeader("X-Late", "1")
+		c.Next()
+	})
+	if w = performRequest(r, http.MethodGet, "/world"); w.Header().Get("X-Late") != "1" {
+		t.Fatal("global middleware added after NoRoute should run")
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	r := New()
+	r.Use(ErrorHandler())
+	r.GET("/private", func(c *Context) {
+		c.AbortWithError(http.StatusServiceUnavailable, errors.New("database is down"))
+	})
+	r.GET("/public", func(c *Context) {
+		c.Error(errors.New("quota exceeded")).SetType(ErrorTypePublic)
+	})
+	r.GET("/bind", func(c *Context) {
+		var obj struct {
+			Name string `form:"name" binding:"required"`
+		}
+		c.Bind(&obj)
+	})
+	r.GET("/written", func(c *Context) {
+		c.Error(errors.New("ignored"))
+		c.String(http.StatusOK, "ok")
+	})
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/private", http.StatusServiceUnavailable, `{"message":"Service Unavailable"}`},
+		{"/public", http.StatusInternalServerError, `{"message":"quota exceeded"}`},
+		{"/bind", http.StatusBadRequest, `{"message":"field 'Name' failed on the 'required' rule"}`},
+		{"/written", http.StatusOK, "ok"},
+	}
+	for _, tc := range cases {
+		w := performRequest(r, http.MethodGet, tc.path)
+		if w.Code != tc.code || strings.TrimSpace(w.Body.String()) != tc.body {
+			t.Errorf("%s: expect %d %s, got %d %s", tc.path, tc.code, tc.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestErrorHandlerWithConfig(t *testing.T) {
+	r := New()
+	r.Use(ErrorHandlerWithConfig(ErrorHandlerConfig{
+		Render: func(c *Context, code int, errs Errors) {
+			c.String(code, "%d errors", len(errs))
+		},
+	}))
+	r.GET("/", func(c *Context) {
+		c.Error(errors.New("first"))
+		c.AbortWithError(http.StatusConflict, errors.New("second"))
+	})
+	if w := performRequest(r, http.MethodGet, "/"); w.Code != http.StatusConflict || w.Body.String() != "2 errors" {
+		t.Fatalf("expect 409 '2 errors', got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestAbortWithErrorWithoutHandler(t *testing.T) {
+	r := New()
+	var after bool
+	r.GET("/", func(c *Context) {
+		c.AbortWithError(http.StatusForbidden, errors.New("forbidden")).SetType(ErrorTypePublic)
+	}, func(c *Context) {
+		after = true
+	})
+	w := performRequest(r, http.MethodGet, "/")
+	if w.Code != http.StatusForbidden || w.Body.String() != "{\"message\":\"forbidden\"}\n" {
+		t.Fatalf("expect default error response, got %d %q", w.Code, w.Body.String())
+	}
+	if after {
+		t.Fatal("AbortWithError should stop the handler chain")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	base := errors.New("base")
+	c := newContext(New())
+	c.Error(base)
+	c.Error(&Error{Err: errors.New("bad input"), Type: ErrorTypeBind}).SetMeta("name")
+
+	if len(c.Errors.ByType(ErrorTypePrivate)) != 1 || len(c.Errors.ByType(ErrorTypePublic|ErrorTypeBind)) != 1 ||
+		len(c.Errors.ByType(ErrorTypeAny)) != 2 {
+		t.Fatalf("unexpected ByType result: %v", c.Errors)
+	}
+	if !errors.Is(c.Errors[0], base) || !c.Errors.Last().IsPublic() {
+		t.Fatal("unexpected error types")
+	}
+	if s := c.Errors.String(); s != "Error #01: base\nError #02: bad input\n     Meta: name\n" {
+		t.Fatalf("unexpected String(): %q", s)
+	}
+}
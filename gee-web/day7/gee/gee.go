@@ -2,15 +2,20 @@ package gee
 
 import (
 	"html/template"
+	"io/fs"
 	"net/http"
-	"path"
 	"strings"
-
-	"github.com/sirupsen/logrus"
+	"sync"
 )
 
 type HandlerFunc func(*Context)
 
+var anyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodHead, http.MethodOptions, http.MethodDelete, http.MethodConnect,
+	http.MethodTrace,
+}
+
 type (
 	RouterGroup struct {
 		prefix      string
@@ -18,19 +23,78 @@ type (
 		parent      *RouterGroup
 		engine      *Engine
 	}
+	// Route 是注册好的一条路由，可以通过 Name 命名后用 Engine.URL 反向生成路径。
+	// 它还记录路由所属的分组和自身的处理函数，用于在分组中间件变化时重新组合处理链
+	Route struct {
+		method   string
+		pattern  string
+		name     string
+		group    *RouterGroup
+		handlers []HandlerFunc
+		nodes    []*node
+		params   []*node // pattern 中按顺序出现的参数，用于 URL 校验约束，'*' 参数为 nil
+	}
 	Engine struct {
 		*RouterGroup
-		router        *router
-		groups        []*RouterGroup
-		htmlTemplates *template.Template // for html render
-		funcMap       template.FuncMap   // for html render
+		router      *router
+		routes      []*Route
+		namedRoutes map[string]*Route
+		noRoute     []HandlerFunc    // NoRoute 设置的处理函数
+		noMethod    []HandlerFunc    // NoMethod 设置的处理函数
+		allNoRoute  []HandlerFunc    // 全局中间件 + noRoute
+		allNoMethod []HandlerFunc    // 全局中间件 + noMethod
+		allOptions  []HandlerFunc    // 全局中间件 + 自动 OPTIONS 响应
+		allRedirect []HandlerFunc    // 全局中间件 + 路径修正重定向
+		pool        sync.Pool        // 复用 Context
+		cookieKeys  [][]byte         // 签名和加密 cookie 的密钥
+		funcMap     template.FuncMap // for html render
+
+		// 路径存在但方法不匹配时返回 405 并设置 Allow 头，否则返回 404
+		HandleMethodNotAllowed bool
+		// 未注册 OPTIONS 路由时自动以 Allow 头响应 OPTIONS 请求
+		HandleOPTIONS bool
+		// 未注册 HEAD 路由时使用 GET 的处理函数响应 HEAD 请求，不写响应体
+		HandleHEAD bool
+		// 路径只差结尾的 '/' 时重定向到已注册的路由，GET 和 HEAD 请求使用 301，其他方法使用 308
+		RedirectTrailingSlash bool
+		// 没有匹配到路由时清理路径中的 "//"、"."、".." 并忽略大小写重新查找，找到时重定向
+		RedirectFixedPath bool
+		// 使用 URL.RawPath 匹配路由，使 %2F 这类编码后的 '/' 可以出现在参数中
+		UseRawPath bool
+		// UseRawPath 为 true 时对参数的值进行 URL 解码
+		UnescapePathValues bool
+		// Context.HTML 使用的模板，通常由 LoadHTMLGlob 等方法设置，也可以设置为 MultiTemplate 或自定义的实现
+		HTMLRender HTMLRender
+		// Context.SecureJSON 输出 JSON 数组时添加的前缀
+		SecureJSONPrefix string
+		// 解析 multipart 表单时保存在内存中的最大字节数，超出部分写入临时文件
+		MaxMultipartMemory int64
+		// Context.ClientIP 是否信任 X-Forwarded-For 和 X-Real-Ip，只应在部署于反向代理之后时开启
+		ForwardedByClientIP bool
+		// Run 系列方法创建的 http.Server 使用的超时等配置
+		Server ServerConfig
+
+		serversMu sync.Mutex
+		servers   map[*http.Server]struct{} // 正在运行的 server，Shutdown 时逐个关闭
 	}
 )
 
 func New() *Engine {
-	engine := &Engine{router: newRouter()}
+	engine := &Engine{
+		router:                 newRouter(),
+		HandleMethodNotAllowed: true,
+		HandleOPTIONS:          true,
+		HandleHEAD:             true,
+		RedirectTrailingSlash:  true,
+		UnescapePathValues:     true,
+		SecureJSONPrefix:       "while(1);",
+		MaxMultipartMemory:     32 << 20, // 32 MB
+	}
 	engine.RouterGroup = &RouterGroup{engine: engine}
-	engine.groups = []*RouterGroup{engine.RouterGroup}
+	engine.pool.New = func() interface{} {
+		return newContext(engine)
+	}
+	engine.rebuildFallbackHandlers()
 	return engine
 }
 
@@ -47,70 +111,228 @@ func (group *RouterGroup) Group(prefix string) *RouterGroup {
 		parent: group,
 		engine: engine,
 	}
-	engine.groups = append(engine.groups, newGroup)
 	return newGroup
 }
 
-func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) {
+// combineHandlers 按 engine -> 父分组 -> 当前分组 -> handlers 的顺序组合处理链
+func (group *RouterGroup) combineHandlers(handlers ...HandlerFunc) []HandlerFunc {
+	size := len(handlers)
+	for g := group; g != nil; g = g.parent {
+		size += len(g.middlewares)
+	}
+	merged := make([]HandlerFunc, size)
+	end := size - len(handlers)
+	copy(merged[end:], handlers)
+	for g := group; g != nil; g = g.parent {
+		end -= len(g.middlewares)
+		copy(merged[end:], g.middlewares)
+	}
+	return merged
+}
+
+// isAncestorOf 判断 other 是否是 group 本身或其子孙分组
+func (group *RouterGroup) isAncestorOf(other *RouterGroup) bool {
+	for g := other; g != nil; g = g.parent {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+func (group *RouterGroup) addRoute(method string, comp string, handlers []HandlerFunc) *Route {
+	if len(handlers) == 0 {
+		panic("there must be at least one handler for route " + method + " " + group.prefix + comp)
+	}
 	pattern := group.prefix + comp
-	logrus.Infof("Route %4s - %s", method, pattern)
-	group.engine.router.addRoute(method, pattern, handler)
+	engine := group.engine
+	nodes := engine.router.addRoute(method, pattern, group.combineHandlers(handlers...))
+	r := &Route{
+		method:   method,
+		pattern:  nodes[0].pattern,
+		group:    group,
+		handlers: handlers,
+		nodes:    nodes,
+		params:   routeParams(nodes[0].pattern),
+	}
+	engine.routes = append(engine.routes, r)
+	return r
 }
 
-func (group *RouterGroup) GET(pattern string, handler HandlerFunc) {
-	group.addRoute("GET", pattern, handler)
+// Handle 以 method 注册路由，handlers 依次执行，最后一个通常是业务处理函数，前面的可以是该路由独有的中间件
+func (group *RouterGroup) Handle(method string, pattern string, handlers ...HandlerFunc) *Route {
+	if method == "" || strings.ToUpper(method) != method {
+		panic("http method " + method + " is not valid")
+	}
+	return group.addRoute(method, pattern, handlers)
 }
 
-func (group *RouterGroup) POST(pattern string, handler HandlerFunc) {
-	group.addRoute("POST", pattern, handler)
+func (group *RouterGroup) GET(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute(http.MethodGet, pattern, handlers)
 }
 
-func (group *RouterGroup) createStaticHandler(relativePath string, fs http.FileSystem) HandlerFunc {
-	absolutePath := path.Join(group.prefix, relativePath)
-	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
-	return func(c *Context) {
-		file := c.Param("filepath")
-		if _, err := fs.Open(file); err != nil {
-			c.Status(http.StatusNotFound)
-			return
-		}
-		fileServer.ServeHTTP(c.Writer, c.Req)
+func (group *RouterGroup) POST(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute(http.MethodPost, pattern, handlers)
+}
+
+func (group *RouterGroup) PUT(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute(http.MethodPut, pattern, handlers)
+}
+
+func (group *RouterGroup) PATCH(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute(http.MethodPatch, pattern, handlers)
+}
+
+func (group *RouterGroup) DELETE(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute(http.MethodDelete, pattern, handlers)
+}
+
+func (group *RouterGroup) HEAD(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute(http.MethodHead, pattern, handlers)
+}
+
+func (group *RouterGroup) OPTIONS(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute(http.MethodOptions, pattern, handlers)
+}
+
+// Any 为所有标准的 http 方法注册同一条路由
+func (group *RouterGroup) Any(pattern string, handlers ...HandlerFunc) {
+	for _, method := range anyMethods {
+		group.addRoute(method, pattern, handlers)
 	}
 }
 
+// Static 以本地目录 root 中的文件响应 relativePath 下的请求，见 StaticFS
 func (group *RouterGroup) Static(relativePath string, root string) {
-	handler := group.createStaticHandler(relativePath, http.Dir(root))
-	urlPattern := path.Join(relativePath, "/*filepath")
-	group.GET(urlPattern, handler)
+	group.StaticFS(relativePath, http.Dir(root))
 }
 
 // 将定义好的middleware加入到group中
-
+// 处理链在注册路由时组合好，Use 可以在注册路由之后调用：该分组及其子分组下已注册的路由会重新组合处理链，
+// 因此中间件总是按 engine -> 父分组 -> 子分组 -> 路由 的顺序执行，与 Use 和注册路由的先后无关。
+// Use 不是并发安全的，应在 Run 之前调用。
 func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
 	group.middlewares = append(group.middlewares, middlewares...)
+	for _, r := range group.engine.routes {
+		if group.isAncestorOf(r.group) {
+			handlers := r.group.combineHandlers(r.handlers...)
+			for _, n := range r.nodes {
+				n.handlers = handlers
+			}
+		}
+	}
+	if group == group.engine.RouterGroup {
+		group.engine.rebuildFallbackHandlers()
+	}
 }
 
-func (engine *Engine) Run(addr string) (err error) {
-	return http.ListenAndServe(addr, engine)
+// NoRoute 设置没有匹配到路由时的处理函数，默认返回 404。
+// 处理函数在全局中间件之后执行，需要自己写出响应
+func (engine *Engine) NoRoute(handlers ...HandlerFunc) {
+	engine.noRoute = handlers
+	engine.rebuildFallbackHandlers()
 }
 
-func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	var middlewares []HandlerFunc
-	for _, group := range engine.groups {
-		if strings.HasPrefix(req.URL.Path, group.prefix) {
-			middlewares = append(middlewares, group.middlewares...)
-		}
+// NoMethod 设置路径存在但方法不匹配时的处理函数，默认返回 405，只在 HandleMethodNotAllowed 为 true 时生效。
+// 执行前 Allow 响应头已经设置好
+func (engine *Engine) NoMethod(handlers ...HandlerFunc) {
+	engine.noMethod = handlers
+	engine.rebuildFallbackHandlers()
+}
+
+// rebuildFallbackHandlers 重新组合未匹配到路由时使用的处理链，全局中间件或 NoRoute/NoMethod 变化时调用
+func (engine *Engine) rebuildFallbackHandlers() {
+	noRoute := engine.noRoute
+	if len(noRoute) == 0 {
+		noRoute = []HandlerFunc{notFound}
+	}
+	noMethod := engine.noMethod
+	if len(noMethod) == 0 {
+		noMethod = []HandlerFunc{methodNotAllowed}
 	}
-	c := newContext(w, req)
-	c.handlers = middlewares
-	c.engine = engine
+	engine.allNoRoute = engine.combineHandlers(noRoute...)
+	engine.allNoMethod = engine.combineHandlers(noMethod...)
+	engine.allOptions = engine.combineHandlers(autoOptions)
+	engine.allRedirect = engine.combineHandlers(redirectFixedPath)
+}
+
+func notFound(c *Context) {
+	c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+}
+
+func methodNotAllowed(c *Context) {
+	c.String(http.StatusMethodNotAllowed, "405 METHOD NOT ALLOWED: %s %s\n", c.Method, c.Path)
+}
+
+func autoOptions(c *Context) {
+	c.Status(http.StatusNoContent)
+}
+
+// redirectFixedPath 重定向到 router 放在 Location 头中的路径
+func redirectFixedPath(c *Context) {
+	code := http.StatusMovedPermanently
+	if c.Method != http.MethodGet && c.Method != http.MethodHead {
+		code = http.StatusPermanentRedirect
+	}
+	c.Status(code)
+}
+
+func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	c := engine.pool.Get().(*Context)
+	c.Reset(w, req)
 	engine.router.handle(c)
+	if c.errorPending && !c.writermem.Written() {
+		// 没有 ErrorHandler 输出 AbortWithError 记录的错误
+		c.errorPending = false
+		renderErrors(c, c.writermem.Status(), c.Errors)
+	}
+	c.writermem.WriteHeaderNow()
+	engine.pool.Put(c)
 }
 
+// SetFuncMap 设置模板函数，内置的 url 函数(见 Engine.URL)可以被同名函数覆盖
 func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
 	engine.funcMap = funcMap
 }
 
+// TemplateFuncs 返回内置模板函数与 SetFuncMap 设置的函数合并后的结果，可以传给 NewMultiTemplate
+func (engine *Engine) TemplateFuncs() template.FuncMap {
+	funcs := template.FuncMap{
+		"url": engine.URL,
+	}
+	for name, fn := range engine.funcMap {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// LoadHTMLGlob 解析匹配 pattern 的模板文件，所有文件共用一个模板集合
 func (engine *Engine) LoadHTMLGlob(pattern string) {
-	engine.htmlTemplates = template.Must(template.New("").Funcs(engine.funcMap).ParseGlob(pattern))
+	engine.loadHTML(func() (*template.Template, error) {
+		return template.New("").Funcs(engine.TemplateFuncs()).ParseGlob(pattern)
+	})
+}
+
+// LoadHTMLFiles 解析 files 中的模板文件，所有文件共用一个模板集合
+func (engine *Engine) LoadHTMLFiles(files ...string) {
+	engine.loadHTML(func() (*template.Template, error) {
+		return template.New("").Funcs(engine.TemplateFuncs()).ParseFiles(files...)
+	})
+}
+
+// LoadHTMLFS 解析 fsys 中匹配 patterns 的模板文件，可以配合 embed.FS 使用
+func (engine *Engine) LoadHTMLFS(fsys fs.FS, patterns ...string) {
+	engine.loadHTML(func() (*template.Template, error) {
+		return template.New("").Funcs(engine.TemplateFuncs()).ParseFS(fsys, patterns...)
+	})
+}
+
+// loadHTML 立即解析一次模板以便尽早发现错误，明确设置了 DebugMode 时之后每次渲染都会重新解析，修改模板后不需要重启
+func (engine *Engine) loadHTML(load func() (*template.Template, error)) {
+	tmpl := template.Must(load())
+	if reloadTemplates() {
+		engine.HTMLRender = HTMLDebug{Load: load}
+	} else {
+		engine.HTMLRender = HTMLProduction{Template: tmpl}
+	}
 }
@@ -0,0 +1,302 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	SetMode(TestMode)
+	os.Exit(m.Run())
+}
+
+func performRequest(engine *Engine, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	r := New()
+	r.GET("/hello", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	r.POST("/hello", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	w := performRequest(r, http.MethodDelete, "/hello")
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Fatalf("expect 405 with Allow header, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	// 其他路径上显式注册的 HEAD 不影响 /hello 的 Allow
+	r.HEAD("/other", func(c *Context) {})
+	w = performRequest(r, http.MethodPut, "/hello")
+	if w.Header().Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Fatalf("unexpected Allow header %q", w.Header().Get("Allow"))
+	}
+	r.HEAD("/hello", func(c *Context) {})
+	w = performRequest(r, http.MethodPut, "/hello")
+	if w.Header().Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Fatalf("HEAD should not be listed twice, got %q", w.Header().Get("Allow"))
+	}
+
+	w = performRequest(r, http.MethodDelete, "/world")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404, got %d", w.Code)
+	}
+
+	r.HandleMethodNotAllowed = false
+	w = performRequest(r, http.MethodDelete, "/hello")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404 when HandleMethodNotAllowed is disabled, got %d", w.Code)
+	}
+}
+
+func TestAutoOptionsAndHead(t *testing.T) {
+	r := New()
+	r.GET("/hello", func(c *Context) {
+		c.SetHeader("X-Hello", "1")
+		c.String(http.StatusOK, "hello")
+	})
+
+	w := performRequest(r, http.MethodOptions, "/hello")
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Fatalf("expect 204 with Allow header, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	w = performRequest(r, http.MethodHead, "/hello")
+	if w.Code != http.StatusOK || w.Header().Get("X-Hello") != "1" || w.Body.Len() != 0 {
+		t.Fatalf("expect 200 without body, got %d %q", w.Code, w.Body.String())
+	}
+
+	r.HandleOPTIONS = false
+	r.HandleHEAD = false
+	if w = performRequest(r, http.MethodOptions, "/hello"); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expect 405 when HandleOPTIONS is disabled, got %d", w.Code)
+	}
+	if w = performRequest(r, http.MethodHead, "/hello"); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expect 405 when HandleHEAD is disabled, got %d", w.Code)
+	}
+}
+
+func TestRouteHandlers(t *testing.T) {
+	r := New()
+	var trace []string
+	r.Use(func(c *Context) {
+		trace = append(trace, "engine")
+	})
+	auth := func(c *Context) {
+		trace = append(trace, "auth")
+		if c.Query("token") == "" {
+			c.Fail(http.StatusUnauthorized, "unauthorized")
+		}
+	}
+	r.PUT("/item", auth, func(c *Context) {
+		trace = append(trace, "handler")
+		c.String(http.StatusOK, "ok")
+	})
+	r.Any("/any", func(c *Context) {
+		c.String(http.StatusOK, c.Method)
+	})
+
+	if w := performRequest(r, http.MethodPut, "/item?token=1"); w.Code != http.StatusOK {
+		t.Fatalf("expect 200, got %d", w.Code)
+	}
+	if !reflect.DeepEqual(trace, []string{"engine", "auth", "handler"}) {
+		t.Fatalf("unexpected handler order %v", trace)
+	}
+
+	trace = nil
+	if w := performRequest(r, http.MethodPut, "/item"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expect 401, got %d", w.Code)
+	}
+	if !reflect.DeepEqual(trace, []string{"engine", "auth"}) {
+		t.Fatalf("handler shouldn't run after Fail, got %v", trace)
+	}
+
+	for _, method := range anyMethods {
+		if w := performRequest(r, method, "/any"); w.Code != http.StatusOK {
+			t.Fatalf("%s /any: expect 200, got %d", method, w.Code)
+		}
+	}
+}
+
+func TestGroupMiddlewares(t *testing.T) {
+	r := New()
+	var trace []string
+	mark := func(name string) HandlerFunc {
+		return func(c *Context) {
+			trace = append(trace, name)
+		}
+	}
+	ok := func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}
+	v1 := r.Group("/v1")
+	v1.GET("/hello", ok)
+	v10 := r.Group("/v10")
+	v10.GET("/hello", ok)
+	admin := v1.Group("/admin")
+	admin.GET("/users", ok)
+	// Use 在注册路由之后调用同样生效
+	v1.Use(mark("v1"))
+	admin.Use(mark("admin"))
+	r.Use(mark("engine"))
+
+	cases := []struct {
+		path  string
+		trace []string
+	}{
+		{"/v1/hello", []string{"engine", "v1"}},
+		{"/v10/hello", []string{"engine"}},
+		{"/v1/admin/users", []string{"engine", "v1", "admin"}},
+		{"/v1/missing", []string{"engine"}},
+	}
+	for _, tc := range cases {
+		trace = nil
+		performRequest(r, http.MethodGet, tc.path)
+		if !reflect.DeepEqual(trace, tc.trace) {
+			t.Fatalf("%s: expect %v, got %v", tc.path, tc.trace, trace)
+		}
+	}
+}
+
+func TestParamInt(t *testing.T) {
+	r := New()
+	r.GET("/items/:id<int>", func(c *Context) {
+		id, err := c.ParamInt("id")
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, H{"id": id})
+	})
+	r.GET("/pages/:n<uint>?", func(c *Context) {
+		n, err := c.ParamUint("n")
+		if err != nil {
+			n = 1
+		}
+		c.JSON(http.StatusOK, H{"page": n})
+	})
+
+	if w := performRequest(r, http.MethodGet, "/items/-3"); w.Code != http.StatusOK || w.Body.String() != "{\"id\":-3}\n" {
+		t.Fatalf("expect id -3, got %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/items/abc"); w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404 for non-integer id, got %d", w.Code)
+	}
+	if w := performRequest(r, http.MethodGet, "/pages"); w.Body.String() != "{\"page\":1}\n" {
+		t.Fatalf("expect default page 1, got %q", w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/pages/5"); w.Body.String() != "{\"page\":5}\n" {
+		t.Fatalf("expect page 5, got %q", w.Body.String())
+	}
+}
+
+func TestRedirectTrailingSlash(t *testing.T) {
+	r := New()
+	r.GET("/hello", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	r.POST("/users/", func(c *Context) {
+		c.String(http.StatusOK, "users")
+	})
+
+	cases := []struct {
+		method, path string
+		code         int
+		location     string
+	}{
+		{http.MethodGet, "/hello", http.StatusOK, ""},
+		{http.MethodGet, "/hello/?a=1", http.StatusMovedPermanently, "/hello?a=1"},
+		{http.MethodPost, "/users", http.StatusPermanentRedirect, "/users/"},
+		{http.MethodPost, "/users/", http.StatusOK, ""},
+		{http.MethodGet, "/world/", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		w := performRequest(r, tc.method, tc.path)
+		if w.Code != tc.code || w.Header().Get("Location") != tc.location {
+			t.Errorf("%s %s: expect %d %q, got %d %q", tc.method, tc.path, tc.code, tc.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+
+	r.RedirectTrailingSlash = false
+	if w := performRequest(r, http.MethodGet, "/hello/"); w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404 when RedirectTrailingSlash is disabled, got %d", w.Code)
+	}
+}
+
+func TestRedirectOpen(t *testing.T) {
+	r := New()
+	r.RedirectFixedPath = true
+	r.GET("/:name", func(c *Context) {
+		c.String(http.StatusOK, c.Param("name"))
+	})
+	r.GET("/static/*filepath", func(c *Context) {})
+
+	for _, path := range []string{"/%5Cevil.com/", "/%5Cevil.com", "//evil.com/", "//evil.com", "/%09/evil.com/", "/%2Fevil.com/"} {
+		w := performRequest(r, http.MethodGet, path)
+		// 清理后的 "/evil.com" 仍是本站的地址，可以重定向
+		if loc := w.Header().Get("Location"); strings.HasPrefix(loc, "//") || strings.HasPrefix(loc, "/\\") || strings.ContainsAny(loc, "\t\n") {
+			t.Errorf("%s: must not redirect to another host, got %d %q", path, w.Code, loc)
+		}
+	}
+	if w := performRequest(r, http.MethodGet, "/gee/"); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/gee" {
+		t.Fatalf("expect redirect to /gee, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestRedirectFixedPath(t *testing.T) {
+	r := New()
+	r.GET("/users/:name/Profile", func(c *Context) {
+		c.String(http.StatusOK, c.Param("name"))
+	})
+	r.GET("/static/*filepath", func(c *Context) {})
+
+	if w := performRequest(r, http.MethodGet, "/USERS/Gee/profile"); w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404 when RedirectFixedPath is disabled, got %d", w.Code)
+	}
+
+	r.RedirectFixedPath = true
+	cases := map[string]string{
+		"/USERS/Gee/profile":          "/users/Gee/Profile",
+		"//users/./x/../Gee/PROFILE/": "/users/Gee/Profile",
+		"/Static/CSS/a.css":           "/static/CSS/a.css",
+	}
+	for path, location := range cases {
+		w := performRequest(r, http.MethodGet, path)
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != location {
+			t.Errorf("%s: expect 301 to %s, got %d %q", path, location, w.Code, w.Header().Get("Location"))
+		}
+	}
+	if w := performRequest(r, http.MethodGet, "/users/../admin"); w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404, got %d", w.Code)
+	}
+}
+
+func TestUseRawPath(t *testing.T) {
+	r := New()
+	r.GET("/files/:name", func(c *Context) {
+		c.String(http.StatusOK, c.Param("name"))
+	})
+
+	if w := performRequest(r, http.MethodGet, "/files/a%2Fb"); w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404 without UseRawPath, got %d", w.Code)
+	}
+
+	r.UseRawPath = true
+	if w := performRequest(r, http.MethodGet, "/files/a%2Fb"); w.Code != http.StatusOK || w.Body.String() != "a/b" {
+		t.Fatalf("expect 200 'a/b', got %d %q", w.Code, w.Body.String())
+	}
+	r.UnescapePathValues = false
+	if w := performRequest(r, http.MethodGet, "/files/a%2Fb"); w.Body.String() != "a%2Fb" {
+		t.Fatalf("expect raw value, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,257 @@
+package gee
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GzipConfig 是 Gzip 中间件的配置
+type GzipConfig struct {
+	// 压缩级别，0 时使用 gzip.DefaultCompression
+	Level int
+	// 小于该长度的响应不压缩，0 时使用 1024
+	MinLength int
+	// 以这些前缀开头的路径不压缩
+	ExcludedPathPrefixes []string
+	// 以这些前缀开头的 Content-Type 不压缩，为空时排除图片、音视频和常见的压缩格式
+	ExcludedContentTypes []string
+}
+
+var defaultExcludedContentTypes = []string{
+	"image/", "video/", "audio/",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-7z-compressed",
+	"application/x-rar-compressed", "application/pdf", "font/woff",
+}
+
+// Gzip 返回按 Accept-Encoding 协商使用 gzip 或 deflate 压缩响应的中间件。
+// 已设置 Content-Encoding 的响应、Range 请求(如静态文件的断点续传)和没有响应体的状态码不会被压缩，
+// 调用 Flush 的流式响应(Context.Stream)会在每次 Flush 时输出已压缩的数据。
+func Gzip(config GzipConfig) HandlerFunc {
+	level := config.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		panic("gee: invalid gzip compression level " + strconv.Itoa(level))
+	}
+	minLength := config.MinLength
+	if minLength == 0 {
+		minLength = 1024
+	}
+	excludedTypes := config.ExcludedContentTypes
+	if len(excludedTypes) == 0 {
+		excludedTypes = defaultExcludedContentTypes
+	}
+	gzipPool := sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, level)
+		return w
+	}}
+	zlibPool := sync.Pool{New: func() interface{} {
+		w, _ := zlib.NewWriterLevel(io.Discard, level)
+		return w
+	}}
+
+	return func(c *Context) {
+		for _, prefix := range config.ExcludedPathPrefixes {
+			if strings.HasPrefix(c.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.Req.Header.Get("Accept-Encoding"))
+		if encoding == "" || c.Req.Header.Get("Range") != "" || c.Req.Header.Get("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			minLength:      minLength,
+			excludedTypes:  excludedTypes,
+		}
+		switch encoding {
+		case "gzip":
+			gz := gzipPool.Get().(*gzip.Writer)
+			defer gzipPool.Put(gz)
+			cw.newCompressor = func(w io.Writer) io.WriteCloser {
+				gz.Reset(w)
+				return gz
+			}
+		case "deflate":
+			zw := zlibPool.Get().(*zlib.Writer)
+			defer zlibPool.Put(zw)
+			cw.newCompressor = func(w io.Writer) io.WriteCloser {
+				zw.Reset(w)
+				return zw
+			}
+		}
+		c.Writer = cw
+		defer func() {
+			cw.close()
+			c.Writer = cw.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding 从 Accept-Encoding 中选出 gzip 或 deflate，优先 gzip，q=0 表示不接受
+func negotiateEncoding(accept string) string {
+	var gzipOK, deflateOK bool
+	for _, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		name, params := part, ""
+		if i := strings.IndexByte(part, ';'); i >= 0 {
+			name, params = strings.TrimSpace(part[:i]), part[i+1:]
+		}
+		if q := strings.TrimSpace(params); strings.HasPrefix(q, "q=") {
+			if v, err := strconv.ParseFloat(q[2:], 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		switch strings.ToLower(name) {
+		case "gzip", "*":
+			gzipOK = true
+		case "deflate":
+			deflateOK = true
+		}
+	}
+	if gzipOK {
+		return "gzip"
+	}
+	if deflateOK {
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter 缓冲响应的开头，达到 MinLength 后才决定是否压缩。
+// 状态码直接交给底层的 ResponseWriter 记录，由于响应头推迟到第一次写响应体时才写出，决定压缩时仍然可以修改响应头。
+type compressWriter struct {
+	ResponseWriter
+	encoding      string
+	minLength     int
+	excludedTypes []string
+	newCompressor func(io.Writer) io.WriteCloser
+
+	buf        []byte
+	wrote      bool // 处理函数已经写过响应体，即使还在缓冲中
+	decided    bool
+	compressor io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	w.ResponseWriter.WriteHeader(code)
+	if !bodyAllowedForStatus(code) {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	if !w.decided {
+		if !w.compressible() {
+			w.decide(false)
+		} else if len(w.buf)+len(b) < w.minLength {
+			w.buf = append(w.buf, b...)
+			return len(b), nil
+		} else {
+			w.decide(true)
+		}
+	}
+	if w.compressor != nil {
+		return w.compressor.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written 在响应体还在缓冲中时也返回 true，避免后续的中间件再写一次响应
+func (w *compressWriter) Written() bool {
+	return w.wrote || w.ResponseWriter.Written()
+}
+
+// WriteHeaderNow 写出响应头之后不能再修改 Content-Encoding，因此先按 Content-Type 决定是否压缩
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide(w.compressible())
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush 用于流式响应，尚未决定时直接按 Content-Type 决定是否压缩
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(w.compressible())
+	}
+	if gz, ok := w.compressor.(interface{ Flush() error }); ok {
+		_ = gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) compressible() bool {
+	if !bodyAllowedForStatus(w.Status()) {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if cl := header.Get("Content-Length"); cl != "" {
+		if n, err := strconv.Atoi(cl); err == nil && n < w.minLength {
+			return false
+		}
+	}
+	contentType := header.Get("Content-Type")
+	for _, prefix := range w.excludedTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// decide 决定是否压缩并写出缓冲的数据，之后的写入直接进入压缩器或底层的 ResponseWriter
+func (w *compressWriter) decide(compress bool) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	if compress {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		w.compressor = w.newCompressor(w.ResponseWriter)
+	}
+	if len(w.buf) > 0 {
+		if w.compressor != nil {
+			_, _ = w.compressor.Write(w.buf)
+		} else {
+			_, _ = w.ResponseWriter.Write(w.buf)
+		}
+		w.buf = nil
+	}
+}
+
+// close 在处理链结束后调用，输出未达到 MinLength 的响应或结束压缩流
+func (w *compressWriter) close() {
+	if !w.decided {
+		if !w.wrote {
+			// 处理函数没有写响应体，响应头由 Engine 在请求结束时写出
+			return
+		}
+		w.decide(false)
+	}
+	if w.compressor != nil {
+		_ = w.compressor.Close()
+	}
+}
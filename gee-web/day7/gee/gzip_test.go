@@ -0,0 +1,95 @@
+package gee
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipRequest(r *Engine, path, encoding string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept-Encoding", encoding)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGzip(t *testing.T) {
+	large := strings.Repeat("geektutu ", 500)
+	r := New()
+	r.Use(Gzip(GzipConfig{ExcludedPathPrefixes: []string{"/raw"}}))
+	r.GET("/large", func(c *Context) {
+		c.JSON(http.StatusOK, H{"data": large})
+	})
+	r.GET("/small", func(c *Context) {
+		c.String(http.StatusOK, "small")
+	})
+	r.GET("/raw/large", func(c *Context) {
+		c.String(http.StatusOK, large)
+	})
+	r.GET("/image", func(c *Context) {
+		c.Render(http.StatusOK, Data{ContentType: "image/png", Data: []byte(large)})
+	})
+	r.GET("/stream", func(c *Context) {
+		i := 0
+		c.Stream(func(w io.Writer) bool {
+			i++
+			c.SSEvent("tick", i)
+			return i < 3
+		})
+	})
+	r.Static("/assets", "../static")
+
+	w := gzipRequest(r, "/large", "gzip, deflate")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expect gzip response, got %v", w.Header())
+	}
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := ioutil.ReadAll(gr); !strings.Contains(string(body), large) {
+		t.Fatal("unexpected decompressed body")
+	}
+
+	w = gzipRequest(r, "/large", "deflate, gzip;q=0")
+	zr, err := zlib.NewReader(w.Body)
+	if err != nil || w.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("expect deflate response, got %v %v", w.Header(), err)
+	}
+	if body, _ := ioutil.ReadAll(zr); !strings.Contains(string(body), large) {
+		t.Fatal("unexpected inflated body")
+	}
+
+	for _, path := range []string{"/small", "/raw/large", "/image"} {
+		w = gzipRequest(r, path, "gzip")
+		if w.Header().Get("Content-Encoding") != "" || w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Fatalf("%s shouldn't be compressed, got %v", path, w.Header())
+		}
+	}
+	if w = gzipRequest(r, "/large", "identity"); w.Header().Get("Content-Encoding") != "" {
+		t.Fatal("response shouldn't be compressed without gzip in Accept-Encoding")
+	}
+
+	w = gzipRequest(r, "/stream", "gzip")
+	if w.Header().Get("Content-Encoding") != "gzip" || !w.Flushed {
+		t.Fatalf("expect flushed gzip stream, got %v", w.Header())
+	}
+	gr, _ = gzip.NewReader(w.Body)
+	if body, _ := ioutil.ReadAll(gr); !strings.Contains(string(body), "data: 3") {
+		t.Fatalf("unexpected stream body %q", body)
+	}
+
+	w = gzipRequest(r, "/assets/file1.txt", "gzip", "Range", "bytes=0-1")
+	if w.Code != http.StatusPartialContent || w.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("range request shouldn't be compressed, got %d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,129 @@
+package gee
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"path/filepath"
+)
+
+// HTMLRender 为 Context.HTML 提供模板，Instance 返回渲染名为 name 的模板的 Render
+type HTMLRender interface {
+	Instance(name string, data interface{}) Render
+}
+
+// HTMLProduction 使用解析好的模板集合，不会重新解析
+type HTMLProduction struct {
+	Template *template.Template
+}
+
+func (r HTMLProduction) Instance(name string, data interface{}) Render {
+	return HTML{Template: r.Template, Name: name, Data: data}
+}
+
+// HTMLDebug 每次渲染前调用 Load 重新解析模板，解析失败时返回 500
+type HTMLDebug struct {
+	Load func() (*template.Template, error)
+}
+
+func (r HTMLDebug) Instance(name string, data interface{}) Render {
+	tmpl, err := r.Load()
+	if err != nil {
+		return errorRender{err}
+	}
+	return HTML{Template: tmpl, Name: name, Data: data}
+}
+
+// MultiTemplate 为每个页面单独解析一个模板集合，页面由布局、局部模板和页面自身的文件组成，
+// 不同页面中同名的 {{define "content"}} 互不影响。渲染时执行第一个文件，通常是布局：
+//
+//	m := gee.NewMultiTemplate(r.TemplateFuncs())
+//	m.AddFromFiles("users/show", "templates/layout.html", "templates/users/show.html")
+//	m.AddFromGlob("users/list", "templates/layout.html", "templates/partials/*.html", "templates/users/list.html")
+//	r.HTMLRender = m
+//
+// 明确设置了 DebugMode 时每次渲染都会重新解析页面的文件。MultiTemplate 不是并发安全的，应在 Run 之前添加页面。
+type MultiTemplate struct {
+	funcMap template.FuncMap
+	pages   map[string]*page
+}
+
+type page struct {
+	tmpl *template.Template
+	load func() (*template.Template, error)
+}
+
+// NewMultiTemplate 返回空的 MultiTemplate，所有页面都可以使用 funcMap 中的函数
+func NewMultiTemplate(funcMap template.FuncMap) *MultiTemplate {
+	return &MultiTemplate{funcMap: funcMap, pages: make(map[string]*page)}
+}
+
+// AddFromFiles 以 files 组成名为 name 的页面，files[0] 是执行的入口
+func (m *MultiTemplate) AddFromFiles(name string, files ...string) *template.Template {
+	if len(files) == 0 {
+		panic("gee: no files for template " + name)
+	}
+	return m.add(name, func() (*template.Template, error) {
+		return template.New(filepath.Base(files[0])).Funcs(m.funcMap).ParseFiles(files...)
+	})
+}
+
+// AddFromGlob 以 layout 和匹配 patterns 的文件组成名为 name 的页面，layout 是执行的入口
+func (m *MultiTemplate) AddFromGlob(name string, layout string, patterns ...string) *template.Template {
+	return m.add(name, func() (*template.Template, error) {
+		tmpl, err := template.New(filepath.Base(layout)).Funcs(m.funcMap).ParseFiles(layout)
+		if err != nil {
+			return nil, err
+		}
+		for _, pattern := range patterns {
+			if tmpl, err = tmpl.ParseGlob(pattern); err != nil {
+				return nil, err
+			}
+		}
+		return tmpl, nil
+	})
+}
+
+// AddFromFS 以 fsys 中的 layout 和匹配 patterns 的文件组成名为 name 的页面，layout 是执行的入口
+func (m *MultiTemplate) AddFromFS(name string, fsys fs.FS, layout string, patterns ...string) *template.Template {
+	return m.add(name, func() (*template.Template, error) {
+		return template.New(path.Base(layout)).Funcs(m.funcMap).ParseFS(fsys, append([]string{layout}, patterns...)...)
+	})
+}
+
+func (m *MultiTemplate) add(name string, load func() (*template.Template, error)) *template.Template {
+	if _, ok := m.pages[name]; ok {
+		panic("gee: template " + name + " is already added")
+	}
+	tmpl := template.Must(load())
+	m.pages[name] = &page{tmpl: tmpl, load: load}
+	return tmpl
+}
+
+func (m *MultiTemplate) Instance(name string, data interface{}) Render {
+	p, ok := m.pages[name]
+	if !ok {
+		return errorRender{fmt.Errorf("html template %q is not added", name)}
+	}
+	tmpl := p.tmpl
+	if reloadTemplates() {
+		var err error
+		if tmpl, err = p.load(); err != nil {
+			return errorRender{err}
+		}
+	}
+	return HTML{Template: tmpl, Name: tmpl.Name(), Data: data}
+}
+
+// errorRender 在渲染时返回 err，用于模板无法加载的情况
+type errorRender struct {
+	err error
+}
+
+func (r errorRender) WriteContentType(http.ResponseWriter) {}
+
+func (r errorRender) Render(http.ResponseWriter) error {
+	return r.err
+}
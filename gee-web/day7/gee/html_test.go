@@ -0,0 +1,165 @@
+package gee
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func writeTemplates(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMultiTemplate(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"layout.html": `<title>{{template "title" .}}</title>{{template "content" .}}`,
+		"nav.html":    `{{define "nav"}}<nav>{{ url "home" }}</nav>{{end}}`,
+		"home.html":   `{{define "title"}}Home{{end}}{{define "content"}}{{template "nav"}}<p>{{.}}</p>{{end}}`,
+		"about.html":  `{{define "title"}}About{{end}}{{define "content"}}<p>about {{.}}</p>{{end}}`,
+	})
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "home", "welcome")
+	}).Name("home")
+	r.GET("/about", func(c *Context) {
+		c.HTML(http.StatusOK, "about", "gee")
+	})
+	r.GET("/missing", func(c *Context) {
+		c.HTML(http.StatusOK, "missing", nil)
+	})
+
+	m := NewMultiTemplate(r.TemplateFuncs())
+	m.AddFromGlob("home", filepath.Join(dir, "layout.html"), filepath.Join(dir, "nav.html"), filepath.Join(dir, "home.html"))
+	m.AddFromFiles("about", filepath.Join(dir, "layout.html"), filepath.Join(dir, "about.html"))
+	r.HTMLRender = m
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/", http.StatusOK, "<title>Home</title><nav>/</nav><p>welcome</p>"},
+		{"/about", http.StatusOK, "<title>About</title><p>about gee</p>"},
+		{"/missing", http.StatusInternalServerError, "html template \"missing\" is not added\n"},
+	}
+	for _, tc := range cases {
+		w := performRequest(r, http.MethodGet, tc.path)
+		if w.Code != tc.code || w.Body.String() != tc.body {
+			t.Errorf("%s: expect %d %q, got %d %q", tc.path, tc.code, tc.body, w.Code, w.Body.String())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("adding a page twice should panic")
+		}
+	}()
+	m.AddFromFiles("about", filepath.Join(dir, "about.html"))
+}
+
+func TestMultiTemplateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"views/layout.html":     {Data: []byte(`[{{template "content" .}}]`)},
+		"views/pages/list.html": {Data: []byte(`{{define "content"}}list {{.}}{{end}}`)},
+	}
+	r := New()
+	m := NewMultiTemplate(nil)
+	m.AddFromFS("list", fsys, "views/layout.html", "views/pages/list.html")
+	r.HTMLRender = m
+	r.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "list", 3)
+	})
+	if w := performRequest(r, http.MethodGet, "/"); w.Body.String() != "[list 3]" {
+		t.Fatalf("unexpected body: %q", w.Body.String())
+	}
+}
+
+func TestLoadHTML(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"a.tmpl": `{{define "a"}}A {{.}}{{end}}`,
+		"b.tmpl": `{{define "b"}}B {{.}}{{end}}`,
+	})
+	fsys := fstest.MapFS{
+		"templates/c.tmpl": {Data: []byte(`{{define "c"}}C {{.}}{{end}}`)},
+	}
+
+	r := New()
+	r.GET("/:name", func(c *Context) {
+		c.HTML(http.StatusOK, c.Param("name"), 1)
+	})
+	load := map[string]func(){
+		"a": func() { r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl")) },
+		"b": func() { r.LoadHTMLFiles(filepath.Join(dir, "b.tmpl")) },
+		"c": func() { r.LoadHTMLFS(fsys, "templates/*.tmpl") },
+	}
+	for name, fn := range load {
+		fn()
+		if _, ok := r.HTMLRender.(HTMLProduction); !ok {
+			t.Fatalf("expect HTMLProduction outside debug mode, got %T", r.HTMLRender)
+		}
+		want := map[string]string{"a": "A 1", "b": "B 1", "c": "C 1"}[name]
+		if w := performRequest(r, http.MethodGet, "/"+name); w.Body.String() != want {
+			t.Errorf("%s: expect %q, got %q", name, want, w.Body.String())
+		}
+	}
+}
+
+func TestHTMLDebugReload(t *testing.T) {
+	defer SetMode(Mode())
+	SetMode(DebugMode)
+
+	dir := writeTemplates(t, map[string]string{
+		"page.tmpl": `{{define "page"}}v1{{end}}`,
+	})
+	r := New()
+	r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+	r.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "page", nil)
+	})
+	if w := performRequest(r, http.MethodGet, "/"); w.Body.String() != "v1" {
+		t.Fatalf("expect v1, got %q", w.Body.String())
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(`{{define "page"}}v2{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if w := performRequest(r, http.MethodGet, "/"); w.Body.String() != "v2" {
+		t.Fatalf("template should be reloaded in debug mode, got %q", w.Body.String())
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(`{{define "page"}}{{end`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if w := performRequest(r, http.MethodGet, "/"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expect 500 for a broken template, got %d", w.Code)
+	}
+}
+
+func TestHTMLDefaultModeNoReload(t *testing.T) {
+	defer SetMode(Mode())
+	// 未设置 GEE_MODE 时虽然是 DebugMode，但模板只解析一次
+	SetMode("")
+
+	dir := writeTemplates(t, map[string]string{
+		"page.tmpl": `{{define "page"}}v1{{end}}`,
+	})
+	r := New()
+	r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+	r.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "page", nil)
+	})
+	if err := ioutil.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(`{{define "page"}}v2{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if w := performRequest(r, http.MethodGet, "/"); w.Body.String() != "v1" {
+		t.Fatalf("template should not be reloaded without an explicit debug mode, got %q", w.Body.String())
+	}
+}
@@ -1,15 +1,149 @@
 package gee
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+// LogParams 是一条访问日志包含的信息
+type LogParams struct {
+	TimeStamp time.Time
+	Method    string
+	Path      string // 请求的原始路径，包含查询参数
+	Route     string // 匹配到的路由，如 /hello/:name，未匹配时为空
+	Proto     string
+	Status    int
+	Size      int // 响应体的字节数
+	Latency   time.Duration
+	ClientIP  string
+	UserAgent string
+	Referer   string
+	RequestID string
+}
+
+// LogFormatter 把 LogParams 格式化为一行日志，不包含结尾的换行
+type LogFormatter func(p LogParams) string
+
+// TextLogFormatter 是默认的文本格式
+func TextLogFormatter(p LogParams) string {
+	route := p.Route
+	if route == "" {
+		route = "-"
+	}
+	line := fmt.Sprintf("[%d] %s %s (%s) %dB in %v | %s | %q",
+		p.Status, p.Method, p.Path, route, p.Size, p.Latency, p.ClientIP, p.UserAgent)
+	if p.RequestID != "" {
+		line += " | " + p.RequestID
+	}
+	return line
+}
+
+// JSONLogFormatter 每行输出一个 JSON 对象，latency 的单位为毫秒
+func JSONLogFormatter(p LogParams) string {
+	data, _ := json.Marshal(struct {
+		Time      string  `json:"time"`
+		Method    string  `json:"method"`
+		Path      string  `json:"path"`
+		Route     string  `json:"route,omitempty"`
+		Status    int     `json:"status"`
+		Size      int     `json:"size"`
+		Latency   float64 `json:"latency_ms"`
+		ClientIP  string  `json:"client_ip"`
+		UserAgent string  `json:"user_agent"`
+		RequestID string  `json:"request_id,omitempty"`
+	}{
+		Time:      p.TimeStamp.Format(time.RFC3339Nano),
+		Method:    p.Method,
+		Path:      p.Path,
+		Route:     p.Route,
+		Status:    p.Status,
+		Size:      p.Size,
+		Latency:   float64(p.Latency) / float64(time.Millisecond),
+		ClientIP:  p.ClientIP,
+		UserAgent: p.UserAgent,
+		RequestID: p.RequestID,
+	})
+	return string(data)
+}
+
+// CombinedLogFormatter 输出 Apache combined 格式
+func CombinedLogFormatter(p LogParams) string {
+	size := "-"
+	if p.Size > 0 {
+		size = fmt.Sprint(p.Size)
+	}
+	return fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s %q %q",
+		p.ClientIP, p.TimeStamp.Format("02/Jan/2006:15:04:05 -0700"),
+		p.Method, p.Path, p.Proto, p.Status, size, p.Referer, p.UserAgent)
+}
+
+// LoggerConfig 是 LoggerWithConfig 的配置
+type LoggerConfig struct {
+	// 日志格式，默认为 TextLogFormatter
+	Formatter LogFormatter
+	// 日志输出，每条日志原样写入一行。默认通过 logrus 标准 logger 以 Info 级别记录，
+	// 使用 logrus 配置的格式、级别和 hook，时间和级别由 logrus 输出
+	Output io.Writer
+	// 不记录日志的路径，如健康检查
+	SkipPaths []string
+}
+
 func Logger() HandlerFunc {
+	return LoggerWithConfig(LoggerConfig{})
+}
+
+// LoggerWithConfig 返回记录访问日志的中间件，在处理链结束后记录状态码、响应大小和耗时
+func LoggerWithConfig(config LoggerConfig) HandlerFunc {
+	formatter := config.Formatter
+	if formatter == nil {
+		formatter = TextLogFormatter
+	}
+	skip := make(map[string]bool, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skip[path] = true
+	}
+	var mu sync.Mutex
+
 	return func(c *Context) {
-		t := time.Now()
+		if skip[c.Path] {
+			c.Next()
+			return
+		}
+		start := time.Now()
 		c.Next()
-		logrus.Printf("[%d] %s in %v", c.StatusCode, c.Req.RequestURI, time.Since(t))
+
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		line := formatter(LogParams{
+			TimeStamp: start,
+			Method:    c.Method,
+			Path:      c.Req.RequestURI,
+			Route:     c.FullPath(),
+			Proto:     c.Req.Proto,
+			Status:    c.Writer.Status(),
+			Size:      size,
+			Latency:   time.Since(start),
+			ClientIP:  c.ClientIP(),
+			UserAgent: c.Req.UserAgent(),
+			Referer:   c.Req.Referer(),
+			RequestID: c.RequestID(),
+		})
+
+		line = strings.TrimRight(line, "\n")
+		if config.Output == nil {
+			logrus.Info(line)
+			return
+		}
+		mu.Lock()
+		_, _ = io.WriteString(config.Output, line+"\n")
+		mu.Unlock()
 	}
 }
@@ -0,0 +1,100 @@
+package gee
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	r := New()
+	r.Use(LoggerWithConfig(LoggerConfig{Output: &buf, SkipPaths: []string{"/health"}}), RequestID())
+	r.GET("/hello/:name", func(c *Context) {
+		c.String(http.StatusOK, "hello %s", c.Param("name"))
+	})
+	r.GET("/health", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.Static("/assets", "../static")
+
+	req := httptest.NewRequest(http.MethodGet, "/hello/geektutu?x=1", nil)
+	req.Header.Set("User-Agent", "gee-test")
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get(RequestIDHeader) != "req-1" {
+		t.Fatalf("request id should be propagated, got %q", w.Header().Get(RequestIDHeader))
+	}
+	expect := `[200] GET /hello/geektutu?x=1 (/hello/:name) 14B in `
+	if line := buf.String(); !strings.HasPrefix(line, expect) || !strings.HasSuffix(line, `| 192.0.2.1 | "gee-test" | req-1`+"\n") {
+		t.Fatalf("unexpected log line %q", line)
+	}
+
+	// 直接写 Writer 的处理函数(http.FileServer)也能记录状态码和大小
+	buf.Reset()
+	performRequest(r, http.MethodGet, "/assets/file1.txt")
+	if line := buf.String(); !strings.HasPrefix(line, "[200] GET /assets/file1.txt (/assets/*filepath) 30B") {
+		t.Fatalf("unexpected log line %q", line)
+	}
+
+	buf.Reset()
+	performRequest(r, http.MethodGet, "/health")
+	performRequest(r, http.MethodGet, "/missing")
+	if line := buf.String(); !strings.HasPrefix(line, "[404] GET /missing (-)") || strings.Count(line, "\n") != 1 {
+		t.Fatalf("unexpected log %q", line)
+	}
+}
+
+func TestLoggerFormats(t *testing.T) {
+	var buf bytes.Buffer
+	r := New()
+	r.Use(RequestIDWithConfig(RequestIDConfig{Generator: func() string { return "generated" }}))
+	r.Use(LoggerWithConfig(LoggerConfig{Output: &buf, Formatter: JSONLogFormatter}))
+	r.POST("/users", func(c *Context) {
+		c.JSON(http.StatusCreated, H{"id": 1})
+	})
+	performRequest(r, http.MethodPost, "/users")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["status"] != float64(201) || entry["route"] != "/users" || entry["request_id"] != "generated" || entry["size"] != float64(9) {
+		t.Fatalf("unexpected json log %v", entry)
+	}
+
+	p := LogParams{Method: "GET", Path: "/a", Proto: "HTTP/1.1", Status: 200, Size: 5, ClientIP: "1.2.3.4", UserAgent: "ua", Referer: "ref"}
+	if line := CombinedLogFormatter(p); !strings.HasPrefix(line, "1.2.3.4 - - [") || !strings.HasSuffix(line, `"GET /a HTTP/1.1" 200 5 "ref" "ua"`) {
+		t.Fatalf("unexpected combined log %q", line)
+	}
+}
+
+func TestLoggerLogrus(t *testing.T) {
+	var buf bytes.Buffer
+	std := logrus.StandardLogger()
+	out, formatter := std.Out, std.Formatter
+	defer func() {
+		logrus.SetOutput(out)
+		logrus.SetFormatter(formatter)
+	}()
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+
+	// 没有设置 Output 时通过 logrus 记录，保留 logrus 的时间、级别和格式
+	r := New()
+	r.Use(Logger())
+	r.GET("/x", func(c *Context) {})
+	performRequest(r, http.MethodGet, "/x")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expect logrus json output, got %q", buf.String())
+	}
+	if entry["level"] != "info" || entry["time"] == nil || !strings.HasPrefix(entry["msg"].(string), "[200] GET /x") {
+		t.Fatalf("unexpected logrus entry %v", entry)
+	}
+}
@@ -0,0 +1,56 @@
+package gee
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// 运行模式，默认为 DebugMode，可以通过环境变量 GEE_MODE 或 SetMode 修改。
+// DebugMode 下启动时会打印路由表；只有明确设置了 DebugMode(GEE_MODE=debug 或 SetMode(DebugMode))时，
+// HTML 模板才会在每次请求时重新解析，未设置 GEE_MODE 的服务与之前一样只解析一次模板。
+const (
+	DebugMode   = "debug"
+	ReleaseMode = "release"
+	TestMode    = "test"
+)
+
+// EnvGeeMode 是设置运行模式的环境变量
+const EnvGeeMode = "GEE_MODE"
+
+var (
+	geeMode         atomic.Value
+	geeModeExplicit int32 // 是否明确设置了运行模式
+)
+
+func init() {
+	SetMode(os.Getenv(EnvGeeMode))
+}
+
+// SetMode 设置运行模式，value 为空时使用 DebugMode
+func SetMode(value string) {
+	explicit := int32(1)
+	switch value {
+	case "":
+		value, explicit = DebugMode, 0
+	case DebugMode, ReleaseMode, TestMode:
+	default:
+		panic("gee: unknown mode " + value)
+	}
+	geeMode.Store(value)
+	atomic.StoreInt32(&geeModeExplicit, explicit)
+}
+
+// Mode 返回当前的运行模式
+func Mode() string {
+	return geeMode.Load().(string)
+}
+
+// IsDebugging 判断是否处于 DebugMode
+func IsDebugging() bool {
+	return Mode() == DebugMode
+}
+
+// reloadTemplates 判断 HTML 模板是否在每次渲染时重新解析，只在明确设置了 DebugMode 时开启
+func reloadTemplates() bool {
+	return IsDebugging() && atomic.LoadInt32(&geeModeExplicit) == 1
+}
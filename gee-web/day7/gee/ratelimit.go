@@ -0,0 +1,250 @@
+package gee
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type RateLimitAlgorithm int
+
+const (
+	// TokenBucket 允许最多 Limit 个请求的突发，令牌在 Window 内匀速补满
+	TokenBucket RateLimitAlgorithm = iota
+	// SlidingWindow 任意长度为 Window 的时间段内最多 Limit 个请求(按前后两个固定窗口加权估算)
+	SlidingWindow
+)
+
+// RateLimitConfig 是 RateLimit 中间件的配置
+type RateLimitConfig struct {
+	Algorithm RateLimitAlgorithm
+	Limit     int
+	Window    time.Duration
+	// 限流的维度，默认按 Context.ClientIP，可以使用 KeyByHeader 或自定义函数
+	KeyFunc func(c *Context) string
+	// 超过该时间没有请求的 key 会被清理，默认为 2 * Window
+	IdleTimeout time.Duration
+}
+
+// rateLimitResult 是一次限流判断的结果
+type rateLimitResult struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration // 配额完全恢复还需要的时间
+	retryAfter time.Duration // 被拒绝时，下一个请求可以通过还需要的时间
+}
+
+// rateLimiter 是在内存中保存每个 key 状态的限流器
+type rateLimiter interface {
+	allow(key string, now time.Time) rateLimitResult
+}
+
+// KeyByIP 按客户端 IP 限流
+func KeyByIP(c *Context) string {
+	return c.ClientIP()
+}
+
+// KeyByHeader 按请求头 name 的值限流，如 API Key
+func KeyByHeader(name string) func(c *Context) string {
+	return func(c *Context) string {
+		return c.Req.Header.Get(name)
+	}
+}
+
+// RateLimit 返回限流中间件，可用于全局、分组或单个路由，每次调用都使用独立的计数。
+// 响应中设置 X-RateLimit-Limit、X-RateLimit-Remaining、X-RateLimit-Reset，超限时返回 429 并设置 Retry-After。
+func RateLimit(config RateLimitConfig) HandlerFunc {
+	if config.Limit <= 0 || config.Window <= 0 {
+		panic("gee: rate limit requires a positive Limit and Window")
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByIP
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = 2 * config.Window
+	}
+	var limiter rateLimiter
+	switch config.Algorithm {
+	case TokenBucket:
+		limiter = newTokenBucketLimiter(config.Limit, config.Window, config.IdleTimeout)
+	case SlidingWindow:
+		limiter = newSlidingWindowLimiter(config.Limit, config.Window, config.IdleTimeout)
+	default:
+		panic("gee: unknown rate limit algorithm " + strconv.Itoa(int(config.Algorithm)))
+	}
+	limit := strconv.Itoa(config.Limit)
+
+	return func(c *Context) {
+		result := limiter.allow(config.KeyFunc(c), time.Now())
+		header := c.Writer.Header()
+		header.Set("X-RateLimit-Limit", limit)
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(result.remaining))
+		header.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.reset)))
+		if !result.allowed {
+			header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.retryAfter)))
+			c.Fail(http.StatusTooManyRequests, "too many requests")
+			return
+		}
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// idleSweeper 记录每个 key 的最后访问时间，定期清理空闲的 key
+type idleSweeper struct {
+	idleTimeout time.Duration
+	lastSweep   time.Time
+}
+
+func (s *idleSweeper) due(now time.Time) bool {
+	if now.Sub(s.lastSweep) < s.idleTimeout {
+		return false
+	}
+	s.lastSweep = now
+	return true
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type tokenBucketLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64 // 每秒补充的令牌数
+	buckets  map[string]*bucket
+	sweeper  idleSweeper
+}
+
+func newTokenBucketLimiter(limit int, window, idleTimeout time.Duration) *tokenBucketLimiter {
+	return &tokenBucketLimiter{
+		capacity: float64(limit),
+		rate:     float64(limit) / window.Seconds(),
+		buckets:  make(map[string]*bucket),
+		sweeper:  idleSweeper{idleTimeout: idleTimeout},
+	}
+}
+
+func (l *tokenBucketLimiter) allow(key string, now time.Time) rateLimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sweeper.due(now) {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) >= l.sweeper.idleTimeout {
+				delete(l.buckets, k)
+			}
+		}
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.capacity, last: now}
+		l.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.capacity, b.tokens+elapsed*l.rate)
+	}
+	b.last = now
+
+	var result rateLimitResult
+	if b.tokens >= 1 {
+		b.tokens--
+		result.allowed = true
+	} else {
+		result.retryAfter = l.duration(1 - b.tokens)
+	}
+	result.remaining = int(b.tokens)
+	result.reset = l.duration(l.capacity - b.tokens)
+	return result
+}
+
+// duration 返回补充 tokens 个令牌需要的时间
+func (l *tokenBucketLimiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+type window struct {
+	start    time.Time // 当前固定窗口的开始时间
+	current  int
+	previous int
+	last     time.Time
+}
+
+type slidingWindowLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	size    time.Duration
+	windows map[string]*window
+	sweeper idleSweeper
+}
+
+func newSlidingWindowLimiter(limit int, size, idleTimeout time.Duration) *slidingWindowLimiter {
+	return &slidingWindowLimiter{
+		limit:   limit,
+		size:    size,
+		windows: make(map[string]*window),
+		sweeper: idleSweeper{idleTimeout: idleTimeout},
+	}
+}
+
+func (l *slidingWindowLimiter) allow(key string, now time.Time) rateLimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sweeper.due(now) {
+		for k, w := range l.windows {
+			if now.Sub(w.last) >= l.sweeper.idleTimeout {
+				delete(l.windows, k)
+			}
+		}
+	}
+
+	start := now.Truncate(l.size)
+	w, ok := l.windows[key]
+	if !ok {
+		w = &window{start: start}
+		l.windows[key] = w
+	}
+	if !w.start.Equal(start) {
+		if start.Sub(w.start) == l.size {
+			w.previous = w.current
+		} else {
+			w.previous = 0
+		}
+		w.current = 0
+		w.start = start
+	}
+	w.last = now
+
+	// 上一个窗口中仍落在滑动窗口内的比例
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(l.size)
+	estimated := float64(w.previous)*weight + float64(w.current)
+
+	var result rateLimitResult
+	if estimated+1 <= float64(l.limit) {
+		w.current++
+		estimated++
+		result.allowed = true
+	} else if w.current >= l.limit || w.previous == 0 {
+		result.retryAfter = l.size - elapsed
+	} else {
+		// 等到上一个窗口的权重下降到可以再放行一个请求
+		need := 1 - (float64(l.limit-w.current-1) / float64(w.previous))
+		result.retryAfter = time.Duration(need*float64(l.size)) - elapsed
+	}
+	result.remaining = l.limit - int(math.Ceil(estimated))
+	if result.remaining < 0 {
+		result.remaining = 0
+	}
+	result.reset = l.size - elapsed
+	if w.current > 0 {
+		result.reset += l.size
+	}
+	return result
+}
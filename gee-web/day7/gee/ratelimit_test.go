@@ -0,0 +1,91 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketLimiter(t *testing.T) {
+	l := newTokenBucketLimiter(3, 3*time.Second, time.Minute)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if r := l.allow("a", now); !r.allowed || r.remaining != 2-i {
+			t.Fatalf("request %d should be allowed, got %+v", i, r)
+		}
+	}
+	r := l.allow("a", now)
+	if r.allowed || r.retryAfter != time.Second {
+		t.Fatalf("burst should be exhausted, got %+v", r)
+	}
+	if r = l.allow("b", now); !r.allowed {
+		t.Fatal("keys should be limited independently")
+	}
+	if r = l.allow("a", now.Add(time.Second)); !r.allowed || r.remaining != 0 {
+		t.Fatalf("one token should be refilled after 1s, got %+v", r)
+	}
+
+	l.allow("c", now.Add(2*time.Minute))
+	if _, ok := l.buckets["a"]; ok || len(l.buckets) != 1 {
+		t.Fatalf("idle keys should be evicted, got %d keys", len(l.buckets))
+	}
+}
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	l := newSlidingWindowLimiter(4, time.Minute, 10*time.Minute)
+	start := time.Now().Truncate(time.Minute)
+	for i := 0; i < 4; i++ {
+		if r := l.allow("a", start.Add(30*time.Second)); !r.allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	r := l.allow("a", start.Add(40*time.Second))
+	if r.allowed || r.retryAfter != 20*time.Second {
+		t.Fatalf("window should be full, got %+v", r)
+	}
+	// 下一个窗口过去 30s 时，上一个窗口的 4 个请求按一半计算
+	if r = l.allow("a", start.Add(90*time.Second)); !r.allowed || r.remaining != 1 {
+		t.Fatalf("expect request allowed with 1 remaining, got %+v", r)
+	}
+	l.allow("a", start.Add(90*time.Second))
+	if r = l.allow("a", start.Add(90*time.Second)); r.allowed || r.retryAfter != 15*time.Second {
+		t.Fatalf("expect request rejected until weight drops, got %+v", r)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	r := New()
+	api := r.Group("/api")
+	api.Use(RateLimit(RateLimitConfig{Limit: 2, Window: time.Minute, KeyFunc: KeyByHeader("X-Api-Key")}))
+	api.GET("/search", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/login", RateLimit(RateLimitConfig{Algorithm: SlidingWindow, Limit: 1, Window: time.Minute}), func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	request := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Api-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	request("/api/search", "k1")
+	w := request("/api/search", "k1")
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "2" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	if w = request("/api/search", "k1"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expect 429 with Retry-After, got %d %v", w.Code, w.Header())
+	}
+	if w = request("/api/search", "k2"); w.Code != http.StatusOK {
+		t.Fatalf("other keys shouldn't be limited, got %d", w.Code)
+	}
+
+	request("/login", "")
+	if w = request("/login", ""); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expect 429 for /login, got %d", w.Code)
+	}
+}
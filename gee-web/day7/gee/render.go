@@ -0,0 +1,302 @@
+package gee
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Render 负责一种响应格式，Context.Render 先调用 WriteContentType 再调用 Render。
+// 实现应当在写出任何内容之前完成编码，这样编码失败时还能返回 500 而不是半个响应。
+type Render interface {
+	Render(w http.ResponseWriter) error
+	WriteContentType(w http.ResponseWriter)
+}
+
+func writeContentType(w http.ResponseWriter, value string) {
+	header := w.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", value)
+	}
+}
+
+type JSON struct {
+	Data interface{}
+}
+
+func (r JSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/json")
+}
+
+func (r JSON) Render(w http.ResponseWriter) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(r.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// IndentedJSON 输出带缩进的 JSON，便于阅读
+type IndentedJSON struct {
+	Data interface{}
+}
+
+func (r IndentedJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/json")
+}
+
+func (r IndentedJSON) Render(w http.ResponseWriter) error {
+	data, err := json.MarshalIndent(r.Data, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// SecureJSON 在 JSON 数组前加上 Prefix，防止 JSON 劫持
+type SecureJSON struct {
+	Prefix string
+	Data   interface{}
+}
+
+func (r SecureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/json")
+}
+
+func (r SecureJSON) Render(w http.ResponseWriter) error {
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(data, []byte("[")) {
+		data = append([]byte(r.Prefix), data...)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// JSONP 以 Callback(data); 的形式输出，Callback 为空时等同于 JSON
+type JSONP struct {
+	Callback string
+	Data     interface{}
+}
+
+func (r JSONP) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/javascript")
+}
+
+func (r JSONP) Render(w http.ResponseWriter) error {
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	if r.Callback == "" {
+		_, err = w.Write(data)
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(template.JSEscapeString(r.Callback))
+	buf.WriteByte('(')
+	buf.Write(data)
+	buf.WriteString(");")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+type XML struct {
+	Data interface{}
+}
+
+func (r XML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/xml")
+}
+
+func (r XML) Render(w http.ResponseWriter) error {
+	data, err := xml.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// String 输出纯文本，Data 不为空时按 Format 格式化
+type String struct {
+	Format string
+	Data   []interface{}
+}
+
+func (r String) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "text/plain")
+}
+
+func (r String) Render(w http.ResponseWriter) error {
+	var err error
+	if len(r.Data) > 0 {
+		_, err = fmt.Fprintf(w, r.Format, r.Data...)
+	} else {
+		_, err = io.WriteString(w, r.Format)
+	}
+	return err
+}
+
+// Redirect 重定向到 Location，Code 必须是 3xx 或 201
+type Redirect struct {
+	Code     int
+	Request  *http.Request
+	Location string
+}
+
+func (r Redirect) WriteContentType(http.ResponseWriter) {}
+
+func (r Redirect) Render(w http.ResponseWriter) error {
+	if (r.Code < http.StatusMultipleChoices || r.Code > http.StatusPermanentRedirect) && r.Code != http.StatusCreated {
+		return fmt.Errorf("cannot redirect with status code %d", r.Code)
+	}
+	http.Redirect(w, r.Request, r.Location, r.Code)
+	return nil
+}
+
+// Data 原样输出字节，ContentType 为空时不设置 Content-Type
+type Data struct {
+	ContentType string
+	Data        []byte
+}
+
+func (r Data) WriteContentType(w http.ResponseWriter) {
+	if r.ContentType != "" {
+		writeContentType(w, r.ContentType)
+	}
+}
+
+func (r Data) Render(w http.ResponseWriter) error {
+	_, err := w.Write(r.Data)
+	return err
+}
+
+// Reader 把 Reader 中的内容复制到响应中，ContentLength 小于 0 时不设置 Content-Length
+type Reader struct {
+	ContentType   string
+	ContentLength int64
+	Reader        io.Reader
+	Headers       map[string]string
+}
+
+func (r Reader) WriteContentType(w http.ResponseWriter) {
+	if r.ContentType != "" {
+		writeContentType(w, r.ContentType)
+	}
+}
+
+func (r Reader) Render(w http.ResponseWriter) error {
+	header := w.Header()
+	if r.ContentLength >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(r.ContentLength, 10))
+	}
+	for k, v := range r.Headers {
+		if header.Get(k) == "" {
+			header.Set(k, v)
+		}
+	}
+	_, err := io.Copy(w, r.Reader)
+	return err
+}
+
+// HTML 执行模板中名为 Name 的模板
+type HTML struct {
+	Template *template.Template
+	Name     string
+	Data     interface{}
+}
+
+func (r HTML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "text/html")
+}
+
+func (r HTML) Render(w http.ResponseWriter) error {
+	if r.Template == nil {
+		return fmt.Errorf("html template %q is not loaded", r.Name)
+	}
+	var buf bytes.Buffer
+	if err := r.Template.ExecuteTemplate(&buf, r.Name, r.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// SSEvent 是一条 Server-Sent Events 消息，Data 为字符串时原样输出，否则编码为 JSON
+type SSEvent struct {
+	Event string
+	Id    string
+	Retry uint
+	Data  interface{}
+}
+
+func (r SSEvent) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	if header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", "no-cache")
+	}
+	if header.Get("Connection") == "" {
+		header.Set("Connection", "keep-alive")
+	}
+}
+
+func (r SSEvent) Render(w http.ResponseWriter) error {
+	var data string
+	switch v := r.Data.(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+	var buf bytes.Buffer
+	if r.Id != "" {
+		buf.WriteString("id: " + sseEscape(r.Id) + "\n")
+	}
+	if r.Event != "" {
+		buf.WriteString("event: " + sseEscape(r.Event) + "\n")
+	}
+	if r.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatUint(uint64(r.Retry), 10) + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func sseEscape(s string) string {
+	return strings.NewReplacer("\n", "\\n", "\r", "\\r").Replace(s)
+}
+
+// bodyAllowedForStatus 与 net/http 中的同名函数一致
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package gee
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type xmlUser struct {
+	Name string `xml:"name"`
+}
+
+func TestRender(t *testing.T) {
+	r := New()
+	r.GET("/json", func(c *Context) {
+		c.JSON(http.StatusCreated, H{"name": "gee"})
+	})
+	r.GET("/indented", func(c *Context) {
+		c.IndentedJSON(http.StatusOK, H{"name": "gee"})
+	})
+	r.GET("/secure", func(c *Context) {
+		c.SecureJSON(http.StatusOK, []int{1, 2})
+	})
+	r.GET("/jsonp", func(c *Context) {
+		c.JSONP(http.StatusOK, H{"name": "gee"})
+	})
+	r.GET("/xml", func(c *Context) {
+		c.XML(http.StatusOK, xmlUser{Name: "gee"})
+	})
+	r.GET("/reader", func(c *Context) {
+		c.DataFromReader(http.StatusOK, 3, "text/csv", strings.NewReader("a,b"), map[string]string{"X-Gee": "1"})
+	})
+	r.GET("/redirect", func(c *Context) {
+		c.Redirect(http.StatusFound, "/json")
+	})
+	r.GET("/broken", func(c *Context) {
+		c.JSON(http.StatusOK, H{"ch": make(chan int)})
+	})
+
+	cases := []struct {
+		path, contentType, body string
+		code                    int
+	}{
+		{"/json", "application/json", "{\"name\":\"gee\"}\n", http.StatusCreated},
+		{"/indented", "application/json", "{\n    \"name\": \"gee\"\n}", http.StatusOK},
+		{"/secure", "application/json", "while(1);[1,2]", http.StatusOK},
+		{"/jsonp?callback=cb", "application/javascript", "cb({\"name\":\"gee\"});", http.StatusOK},
+		{"/xml", "application/xml", "<name>gee</name>", http.StatusOK},
+		{"/reader", "text/csv", "a,b", http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := performRequest(r, http.MethodGet, tc.path)
+		if w.Code != tc.code || w.Header().Get("Content-Type") != tc.contentType || !strings.Contains(w.Body.String(), tc.body) {
+			t.Fatalf("%s: unexpected response %d %q %q", tc.path, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+
+	if w := performRequest(r, http.MethodGet, "/redirect"); w.Code != http.StatusFound || w.Header().Get("Location") != "/json" {
+		t.Fatalf("expect redirect to /json, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	if w := performRequest(r, http.MethodGet, "/broken"); w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "{") {
+		t.Fatalf("expect a clean 500 response, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestStreamAndSSEvent(t *testing.T) {
+	r := New()
+	r.GET("/events", func(c *Context) {
+		i := 0
+		clientGone := c.Stream(func(w io.Writer) bool {
+			i++
+			c.SSEvent("progress", H{"step": i})
+			return i < 3
+		})
+		if clientGone {
+			t.Error("client shouldn't be gone")
+		}
+	})
+	w := performRequest(r, http.MethodGet, "/events")
+	expect := "event: progress\ndata: {\"step\":1}\n\n" +
+		"event: progress\ndata: {\"step\":2}\n\n" +
+		"event: progress\ndata: {\"step\":3}\n\n"
+	if w.Code != http.StatusOK || w.Body.String() != expect || !w.Flushed {
+		t.Fatalf("unexpected stream response %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx)
+	r.GET("/stream", func(c *Context) {
+		steps := 0
+		gone := c.Stream(func(w io.Writer) bool {
+			steps++
+			if steps == 2 {
+				cancel()
+			}
+			_, _ = io.WriteString(w, "tick\n")
+			return true
+		})
+		if !gone || steps != 2 {
+			t.Errorf("stream should stop after client disconnects, steps=%d", steps)
+		}
+	})
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
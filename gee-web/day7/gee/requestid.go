@@ -0,0 +1,68 @@
+package gee
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	// RequestIDHeader 是传递请求 id 的请求头和响应头
+	RequestIDHeader = "X-Request-ID"
+	requestIDKey    = "gee/request-id"
+)
+
+// RequestIDConfig 是 RequestIDWithConfig 的配置
+type RequestIDConfig struct {
+	// 生成新的请求 id，默认为 32 位十六进制随机字符串
+	Generator func() string
+	// 请求头的名字，默认为 X-Request-ID
+	Header string
+}
+
+func RequestID() HandlerFunc {
+	return RequestIDWithConfig(RequestIDConfig{})
+}
+
+// RequestIDWithConfig 返回传递请求 id 的中间件：请求头中带有合法的 id 时沿用，否则生成新的 id，
+// id 保存在 Context 中并写入响应头，通过 Context.RequestID 读取
+func RequestIDWithConfig(config RequestIDConfig) HandlerFunc {
+	if config.Generator == nil {
+		config.Generator = newRequestID
+	}
+	if config.Header == "" {
+		config.Header = RequestIDHeader
+	}
+	return func(c *Context) {
+		id := c.Req.Header.Get(config.Header)
+		if !validRequestID(id) {
+			id = config.Generator()
+		}
+		c.Set(requestIDKey, id)
+		c.SetHeader(config.Header, id)
+		c.Next()
+	}
+}
+
+// RequestID 返回 RequestID 中间件设置的请求 id，没有时返回空字符串
+func (c *Context) RequestID() string {
+	return c.GetString(requestIDKey)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID 拒绝过长或包含不可见字符的 id，避免污染日志
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
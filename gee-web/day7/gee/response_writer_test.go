@@ -0,0 +1,157 @@
+package gee
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var w responseWriter
+	w.reset(rec)
+
+	if w.Written() || w.Status() != http.StatusOK || w.Size() != -1 {
+		t.Fatalf("unexpected initial state: written=%v status=%d size=%d", w.Written(), w.Status(), w.Size())
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("X-Later", "1")
+	if w.Written() || rec.Code != http.StatusOK || rec.Flushed {
+		t.Fatal("WriteHeader should not write the header immediately")
+	}
+	w.Write([]byte("hello "))
+	w.WriteString("gee")
+	w.WriteHeader(http.StatusAccepted) // 已经写出，忽略
+	if !w.Written() || w.Status() != http.StatusCreated || w.Size() != 9 {
+		t.Fatalf("unexpected state: written=%v status=%d size=%d", w.Written(), w.Status(), w.Size())
+	}
+	if rec.Code != http.StatusCreated || rec.Header().Get("X-Later") != "1" || rec.Body.String() != "hello gee" {
+		t.Fatalf("unexpected response: %d %v %q", rec.Code, rec.Header(), rec.Body.String())
+	}
+	if w.Pusher() != nil {
+		t.Fatal("httptest.ResponseRecorder does not support server push")
+	}
+	if w.CloseNotify() == nil {
+		t.Fatal("CloseNotify should never return nil")
+	}
+	if _, _, err := w.Hijack(); err == nil {
+		t.Fatal("httptest.ResponseRecorder does not support Hijack")
+	}
+}
+
+func TestDeferredHeader(t *testing.T) {
+	r := New()
+	r.Use(func(c *Context) {
+		c.Next()
+		// 处理函数只设置了状态码，响应头还可以修改
+		c.SetHeader("X-Handled", "true")
+	})
+	r.GET("/created", func(c *Context) {
+		c.Status(http.StatusCreated)
+	})
+	r.GET("/direct", func(c *Context) {
+		c.Writer.WriteHeader(http.StatusAccepted)
+		c.Writer.Write([]byte("direct"))
+	})
+
+	w := performRequest(r, http.MethodGet, "/created")
+	if header := w.Result().Header; w.Code != http.StatusCreated || header.Get("X-Handled") != "true" {
+		t.Fatalf("expect 201 with X-Handled header, got %d %v", w.Code, header)
+	}
+	w = performRequest(r, http.MethodGet, "/direct")
+	// 响应头已经写出，之后的修改不会发送给客户端
+	if header := w.Result().Header; w.Code != http.StatusAccepted || header.Get("X-Handled") != "" || w.Body.String() != "direct" {
+		t.Fatalf("expect 202 'direct' without X-Handled header, got %d %v %q", w.Code, header, w.Body.String())
+	}
+}
+
+func TestLoggerDirectWrites(t *testing.T) {
+	var buf bytes.Buffer
+	r := New()
+	r.Use(LoggerWithConfig(LoggerConfig{
+		Output: &buf,
+		Formatter: func(p LogParams) string {
+			return p.Path + " " + http.StatusText(p.Status) + " " + strconv.Itoa(p.Size)
+		},
+	}))
+	r.Static("/assets", "../static")
+	r.GET("/direct", func(c *Context) {
+		c.Writer.Write([]byte("abc"))
+	})
+
+	performRequest(r, http.MethodGet, "/direct")
+	performRequest(r, http.MethodGet, "/assets/missing.css")
+	if got := buf.String(); got != "/direct OK 3\n/assets/missing.css Not Found 35\n" {
+		t.Fatalf("unexpected log: %q", got)
+	}
+}
+
+func TestHijack(t *testing.T) {
+	r := New()
+	r.GET("/hijack", func(c *Context) {
+		conn, rw, err := c.Writer.Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 6\r\nConnection: close\r\n\r\nraw ok")
+		rw.Flush()
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /hijack HTTP/1.1\r\nHost: gee\r\n\r\n"))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body := make([]byte, 6)
+	if _, err := resp.Body.Read(body); err != nil && string(body) != "raw ok" {
+		t.Fatal(err)
+	}
+	if string(body) != "raw ok" {
+		t.Fatalf("expect hijacked response, got %q", body)
+	}
+}
+
+func TestContextStatusCode(t *testing.T) {
+	r := New()
+	var got []int
+	r.Use(func(c *Context) {
+		c.Next()
+		got = append(got, c.StatusCode, c.Writer.Status())
+	})
+	r.GET("/created", func(c *Context) {
+		c.String(http.StatusCreated, "ok")
+	})
+	r.GET("/direct", func(c *Context) {
+		c.Writer.WriteHeader(http.StatusAccepted)
+	})
+	r.GET("/default", func(c *Context) {})
+
+	for path, code := range map[string]int{"/created": 201, "/direct": 202, "/default": 200, "/missing": 404} {
+		got = nil
+		performRequest(r, http.MethodGet, path)
+		if len(got) != 2 || got[0] != code || got[1] != code {
+			t.Errorf("%s: expect StatusCode %d, got %v", path, code, got)
+		}
+	}
+
+	c := newContext(r)
+	c.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	c.Status(http.StatusTeapot)
+	if cp := c.Copy(); cp.StatusCode != http.StatusTeapot || cp.Writer.Status() != http.StatusTeapot {
+		t.Fatalf("copy should keep the status code, got %d", cp.StatusCode)
+	}
+}
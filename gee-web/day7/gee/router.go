@@ -1,19 +1,22 @@
 package gee
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"path"
+	"sort"
 	"strings"
 )
 
 type router struct {
-	roots    map[string]*node
-	handlers map[string]HandlerFunc
+	roots     map[string]*node
+	maxParams int // 单条路由中参数的最大个数，用于预分配 Context.Params
 }
 
 func newRouter() *router {
 	return &router{
-		roots:    make(map[string]*node),
-		handlers: make(map[string]HandlerFunc),
+		roots: make(map[string]*node),
 	}
 }
 
@@ -31,43 +34,178 @@ func parsePattern(pattern string) []string {
 	return parts
 }
 
-func (r *router) addRoute(method string, pattern string, handlerFunc HandlerFunc) {
-	parts := parsePattern(pattern)
+// cleanPattern 去掉重复的 '/'，并保证以 '/' 开头，结尾的 '/' 会保留，"/hello" 和 "/hello/" 是两条路由
+func cleanPattern(pattern string) string {
+	var buf strings.Builder
+	buf.Grow(len(pattern) + 1)
+	buf.WriteByte('/')
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '/' && strings.HasSuffix(buf.String(), "/") {
+			continue
+		}
+		buf.WriteByte(pattern[i])
+	}
+	return buf.String()
+}
+
+// cleanPath 与 path.Clean 相同，但保留结尾的 '/'
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	clean := path.Clean("/" + p)
+	if p[len(p)-1] == '/' && clean != "/" {
+		clean += "/"
+	}
+	return clean
+}
+
+// expandOptional 把末尾的可选参数展开，"/archive/:year?/:month?" 展开为
+// "/archive/:year/:month"、"/archive/:year" 和 "/archive"，可选参数之后只能是可选参数
+func expandOptional(pattern string) []string {
+	segments := strings.Split(pattern, "/")[1:]
+	first := -1
+	for i, seg := range segments {
+		optional := len(seg) > 2 && seg[0] == ':' && seg[len(seg)-1] == '?'
+		if optional {
+			segments[i] = seg[:len(seg)-1]
+			if first < 0 {
+				first = i
+			}
+		} else if first >= 0 {
+			panic(fmt.Sprintf("optional parameter must be at the end of pattern '%s'", pattern))
+		}
+	}
+	if first < 0 {
+		return []string{pattern}
+	}
+	patterns := make([]string, 0, len(segments)-first+1)
+	for end := len(segments); end >= first; end-- {
+		patterns = append(patterns, "/"+strings.Join(segments[:end], "/"))
+	}
+	return patterns
+}
+
+// addRoute 注册路由，handlers 为完整的处理链，返回路由所在的节点，含可选参数的路由对应多个节点
+func (r *router) addRoute(method string, pattern string, handlers []HandlerFunc) []*node {
+	pattern = cleanPattern(pattern)
 
-	key := method + "-" + pattern
 	_, ok := r.roots[method]
 	if !ok {
 		r.roots[method] = &node{}
 	}
-	r.roots[method].insert(pattern, parts, 0)
-	r.handlers[key] = handlerFunc
+	expanded := expandOptional(pattern)
+	nodes := make([]*node, 0, len(expanded))
+	for _, p := range expanded {
+		n := r.roots[method].insert(p)
+		if n.pattern != "" {
+			panic(fmt.Sprintf("route '%s %s' is already registered", method, p))
+		}
+		n.pattern = pattern
+		n.handlers = handlers
+		if count := strings.Count(p, "/:") + strings.Count(p, "/*"); count > r.maxParams {
+			r.maxParams = count
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
 }
 
-func (r *router) getRoute(method string, path string) (*node, map[string]string) {
-	searchParts := parsePattern(path)
-	params := make(map[string]string)
+// registered 判断不含参数的 pattern 是否已经注册为 method 的路由
+func (r *router) registered(method string, pattern string) bool {
 	root, ok := r.roots[method]
-
 	if !ok {
+		return false
+	}
+	n := root.lookup(cleanPattern(pattern))
+	return n != nil && n.pattern != ""
+}
+
+func (r *router) getRoute(method string, path string) (*node, Params) {
+	params := make(Params, 0, r.maxParams)
+	n := r.search(method, path, &params)
+	if n == nil {
 		return nil, nil
 	}
+	return n, params
+}
 
-	n := root.search(searchParts, 0)
+// search 查找 method 下与 path 匹配的路由，参数追加到 params 中，匹配失败时 params 保持不变
+func (r *router) search(method string, path string, params *Params) *node {
+	root, ok := r.roots[method]
+	if !ok {
+		return nil
+	}
 
-	if n != nil {
-		parts := parsePattern(n.pattern)
-		for index, part := range parts {
-			if part[0] == ':' {
-				params[part[1:]] = searchParts[index]
-			}
-			if part[0] == '*' && len(part) > 1 {
-				params[part[1:]] = strings.Join(searchParts[index:], "/")
-				break
+	return root.search(path, params)
+}
+
+// exists 判断 method 下是否有与 path 匹配的路由，HEAD 请求在 HandleHEAD 开启时也会查找 GET 路由
+func (r *router) exists(method string, path string, handleHEAD bool) bool {
+	if r.search(method, path, new(Params)) != nil {
+		return true
+	}
+	return method == http.MethodHead && handleHEAD && r.search(http.MethodGet, path, new(Params)) != nil
+}
+
+// trailingSlashPath 返回增加或去掉结尾的 '/' 之后能匹配到路由的路径
+func (r *router) trailingSlashPath(method string, path string, handleHEAD bool) (string, bool) {
+	if path == "/" {
+		return "", false
+	}
+	var alt string
+	if strings.HasSuffix(path, "/") {
+		alt = path[:len(path)-1]
+	} else {
+		alt = path + "/"
+	}
+	return alt, r.exists(method, alt, handleHEAD)
+}
+
+// safeRedirectPath 判断 p 能否作为重定向地址，浏览器会把 "//evil.com"、"/\evil.com" 当作其他站点，
+// 也会忽略地址中的制表符和换行，这样的地址都不能放进 Location
+func safeRedirectPath(p string) bool {
+	if len(p) == 0 || p[0] != '/' {
+		return false
+	}
+	if len(p) > 1 && (p[1] == '/' || p[1] == '\\') {
+		return false
+	}
+	for i := 0; i < len(p); i++ {
+		if p[i] < 0x20 || p[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// fixedPath 清理 path 后忽略大小写查找路由，返回路由中实际的写法，trailingSlash 为 true 时还会尝试增减结尾的 '/'
+func (r *router) fixedPath(method string, path string, trailingSlash bool, handleHEAD bool) (string, bool) {
+	methods := []string{method}
+	if method == http.MethodHead && handleHEAD {
+		methods = append(methods, http.MethodGet)
+	}
+	path = cleanPath(path)
+	candidates := []string{path}
+	if trailingSlash && path != "/" {
+		if strings.HasSuffix(path, "/") {
+			candidates = append(candidates, path[:len(path)-1])
+		} else {
+			candidates = append(candidates, path+"/")
+		}
+	}
+	for _, m := range methods {
+		root, ok := r.roots[m]
+		if !ok {
+			continue
+		}
+		for _, p := range candidates {
+			if fixed, ok := root.searchCaseInsensitive(p, make([]byte, 0, len(p))); ok {
+				return string(fixed), true
 			}
 		}
-		return n, params
 	}
-	return nil, nil
+	return "", false
 }
 
 func (r *router) getRoutes(method string) []*node {
@@ -80,14 +218,114 @@ func (r *router) getRoutes(method string) []*node {
 	return nodes
 }
 
+// allowed 返回 path 在其他方法下已注册的方法，用于 Allow 响应头
+func (r *router) allowed(path string, reqMethod string) []string {
+	methods := make([]string, 0, len(r.roots))
+	for method := range r.roots {
+		if method == reqMethod || method == http.MethodOptions {
+			continue
+		}
+		if n := r.search(method, path, new(Params)); n != nil {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// headResponseWriter 用于以 GET 的处理函数响应 HEAD 请求，丢弃响应体
+type headResponseWriter struct {
+	ResponseWriter
+}
+
+func (w headResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeaderNow()
+	return len(b), nil
+}
+
+func (w headResponseWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	return len(s), nil
+}
+
 func (r *router) handle(c *Context) {
-	n, params := r.getRoute(c.Method, c.Path)
+	engine := c.engine
+	method := c.Method
+	path := c.Path
+	unescape := false
+	if engine.UseRawPath && c.Req.URL.RawPath != "" {
+		path = c.Req.URL.RawPath
+		unescape = engine.UnescapePathValues
+	}
+	n := r.search(method, path, &c.Params)
+	if n == nil && method == http.MethodHead && engine.HandleHEAD {
+		if n = r.search(http.MethodGet, path, &c.Params); n != nil {
+			method = http.MethodGet
+			c.Writer = headResponseWriter{c.Writer}
+		}
+	}
+
 	if n != nil {
-		c.Params = params
-		key := c.Method + "-" + n.pattern
-		c.handlers = append(c.handlers, r.handlers[key])
-	} else {
-		c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+		if unescape {
+			for i, p := range c.Params {
+				if value, err := url.PathUnescape(p.Value); err == nil {
+					c.Params[i].Value = value
+				}
+			}
+		}
+		c.fullPath = n.pattern
+		c.handlers = n.handlers
+		c.Next()
+		return
+	}
+
+	if method != http.MethodConnect {
+		location, ok := "", false
+		if engine.RedirectTrailingSlash {
+			location, ok = r.trailingSlashPath(method, path, engine.HandleHEAD)
+		}
+		if !ok && engine.RedirectFixedPath {
+			location, ok = r.fixedPath(method, path, engine.RedirectTrailingSlash, engine.HandleHEAD)
+		}
+		if ok && safeRedirectPath(location) {
+			if q := c.Req.URL.RawQuery; q != "" {
+				location += "?" + q
+			}
+			c.SetHeader("Location", location)
+			c.handlers = engine.allRedirect
+			c.Next()
+			return
+		}
+	}
+
+	allowed := r.allowed(path, method)
+	if len(allowed) > 0 {
+		if engine.HandleHEAD {
+			hasGet, hasHead := false, false
+			for _, m := range allowed {
+				hasGet = hasGet || m == http.MethodGet
+				hasHead = hasHead || m == http.MethodHead
+			}
+			if hasGet && !hasHead {
+				allowed = append(allowed, http.MethodHead)
+			}
+		}
+		if engine.HandleOPTIONS {
+			allowed = append(allowed, http.MethodOptions)
+		}
+		sort.Strings(allowed)
+	}
+	allow := strings.Join(allowed, ", ")
+
+	// 未匹配到路由时只执行 engine 上的全局中间件
+	switch {
+	case allow != "" && method == http.MethodOptions && engine.HandleOPTIONS:
+		c.SetHeader("Allow", allow)
+		c.handlers = engine.allOptions
+	case allow != "" && engine.HandleMethodNotAllowed:
+		c.SetHeader("Allow", allow)
+		c.handlers = engine.allNoMethod
+	default:
+		c.handlers = engine.allNoRoute
 	}
 	c.Next()
 }
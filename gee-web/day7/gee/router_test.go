@@ -37,24 +37,24 @@ func TestGetRoute(t *testing.T) {
 		t.Fatal("should match /hello/:name")
 	}
 
-	if ps["name"] != "geektutu" {
+	if ps.ByName("name") != "geektutu" {
 		t.Fatal("name should be equal to 'geektutu'")
 	}
 
-	fmt.Printf("matched path: %s, params['name']: %s\n", n.pattern, ps["name"])
+	fmt.Printf("matched path: %s, params['name']: %s\n", n.pattern, ps.ByName("name"))
 
 }
 
 func TestGetRoute2(t *testing.T) {
 	r := newTestRouter()
 	n1, ps1 := r.getRoute("GET", "/assets/file1.txt")
-	ok1 := n1.pattern == "/assets/*filepath" && ps1["filepath"] == "file1.txt"
+	ok1 := n1.pattern == "/assets/*filepath" && ps1.ByName("filepath") == "file1.txt"
 	if !ok1 {
 		t.Fatal("pattern shoule be /assets/*filepath & filepath shoule be file1.txt")
 	}
 
 	n2, ps2 := r.getRoute("GET", "/assets/css/test.css")
-	ok2 := n2.pattern == "/assets/*filepath" && ps2["filepath"] == "css/test.css"
+	ok2 := n2.pattern == "/assets/*filepath" && ps2.ByName("filepath") == "css/test.css"
 	if !ok2 {
 		t.Fatal("pattern shoule be /assets/*filepath & filepath shoule be css/test.css")
 	}
@@ -72,3 +72,130 @@ func TestGetRoutes(t *testing.T) {
 		t.Fatal("the number of routes shoule be 4")
 	}
 }
+
+func TestGetRoutePriority(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/user/new", nil)
+	r.addRoute("GET", "/user/:id", nil)
+	r.addRoute("GET", "/user/:id/files/*path", nil)
+	r.addRoute("GET", "/user/*rest", nil)
+
+	cases := []struct {
+		path, pattern string
+		params        Params
+	}{
+		{"/user/new", "/user/new", Params{}},
+		{"/user/ne", "/user/:id", Params{{"id", "ne"}}},
+		{"/user/42", "/user/:id", Params{{"id", "42"}}},
+		{"/user/42/files/a/b.txt", "/user/:id/files/*path", Params{{"id", "42"}, {"path", "a/b.txt"}}},
+		{"/user/42/other", "/user/*rest", Params{{"rest", "42/other"}}},
+		{"/user/new/", "/user/*rest", Params{{"rest", "new/"}}},
+	}
+	for _, c := range cases {
+		n, ps := r.getRoute("GET", c.path)
+		if n == nil || n.pattern != c.pattern || !reflect.DeepEqual(ps, c.params) {
+			t.Fatalf("%s: expect %s %v, got %v %v", c.path, c.pattern, c.params, n, ps)
+		}
+	}
+	if n, _ := r.getRoute("GET", "/users"); n != nil {
+		t.Fatalf("/users shouldn't be matched, got %v", n)
+	}
+}
+
+func TestAddRouteConflict(t *testing.T) {
+	patterns := [][2]string{
+		{"/hello/:name", "/hello/:id"},
+		{"/hello/:name", "/hello/:name"},
+		{"/assets/*filepath", "/assets/*path"},
+		{"/hello", "/hello/*path/more"},
+		{"/hello", "/hello/:"},
+		{"/users/:id<int>", "/users/:uid<int>"},
+		{"/users/:id<[0-9+>", "/users/:name"},
+		{"/users/:id<>", "/users/:name"},
+		{"/users", "/users/:id?/edit"},
+	}
+	for _, p := range patterns {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("adding %s after %s should panic", p[1], p[0])
+				}
+			}()
+			r := newRouter()
+			r.addRoute("GET", p[0], nil)
+			r.addRoute("GET", p[1], nil)
+		}()
+	}
+}
+
+func TestParamConstraints(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/users/:id<int>", nil)
+	r.addRoute("GET", "/users/:slug<[a-z-]+>", nil)
+	r.addRoute("GET", "/users/:name", nil)
+	r.addRoute("GET", "/posts/:id<uint>/comments", nil)
+	r.addRoute("GET", "/posts/:title", nil)
+
+	cases := []struct {
+		path, pattern, key, value string
+	}{
+		{"/users/-12", "/users/:id<int>", "id", "-12"},
+		{"/users/hello-world", "/users/:slug<[a-z-]+>", "slug", "hello-world"},
+		{"/users/Gee_1", "/users/:name", "name", "Gee_1"},
+		{"/posts/7/comments", "/posts/:id<uint>/comments", "id", "7"},
+		// 约束匹配但后续路径不匹配时回溯到其他分支
+		{"/posts/7", "/posts/:title", "title", "7"},
+	}
+	for _, tc := range cases {
+		n, ps := r.getRoute("GET", tc.path)
+		if n == nil || n.pattern != tc.pattern || ps.ByName(tc.key) != tc.value || len(ps) != 1 {
+			t.Errorf("%s: expect %s with %s=%s, got %v %v", tc.path, tc.pattern, tc.key, tc.value, n, ps)
+		}
+	}
+	if n, _ := r.getRoute("GET", "/posts/x/comments"); n != nil {
+		t.Fatalf("/posts/x/comments shouldn't be matched, got %v", n)
+	}
+}
+
+func TestOptionalParams(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/archive/:year<uint>?/:month<uint>?", nil)
+
+	for path, want := range map[string]int{"/archive": 0, "/archive/2021": 1, "/archive/2021/08": 2} {
+		n, ps := r.getRoute("GET", path)
+		if n == nil || n.pattern != "/archive/:year<uint>?/:month<uint>?" || len(ps) != want {
+			t.Errorf("%s: expect %d params, got %v %v", path, want, n, ps)
+		}
+	}
+	if n, _ := r.getRoute("GET", "/archive/latest"); n != nil {
+		t.Fatalf("/archive/latest shouldn't be matched, got %v", n)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("optional segment conflicting with an existing route should panic")
+		}
+	}()
+	r.addRoute("GET", "/archive", nil)
+}
+
+func TestCleanPath(t *testing.T) {
+	cases := map[string]string{
+		"":            "/",
+		"/":           "/",
+		"a/b":         "/a/b",
+		"//a//b/":     "/a/b/",
+		"/a/./b/../c": "/a/c",
+		"/a/b/../../": "/",
+		"/../a/":      "/a/",
+		"/a/b/./":     "/a/b/",
+	}
+	for in, want := range cases {
+		if got := cleanPath(in); got != want {
+			t.Errorf("cleanPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := cleanPattern("//v1//users/:id/"); got != "/v1/users/:id/" {
+		t.Errorf("cleanPattern keeps trailing slash, got %q", got)
+	}
+}
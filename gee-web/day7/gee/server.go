@@ -0,0 +1,151 @@
+package gee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ServerConfig 是 Run 系列方法创建 http.Server 时使用的配置，零值表示不限制，与 net/http 一致
+type ServerConfig struct {
+	ReadTimeout       time.Duration // 读取整个请求(含请求体)的超时
+	ReadHeaderTimeout time.Duration // 读取请求头的超时，为 0 时使用 ReadTimeout
+	WriteTimeout      time.Duration // 从读完请求头到写完响应的超时
+	IdleTimeout       time.Duration // keep-alive 连接的空闲超时，为 0 时使用 ReadTimeout
+	MaxHeaderBytes    int           // 请求头的最大字节数，为 0 时使用 http.DefaultMaxHeaderBytes
+}
+
+func (engine *Engine) newServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           engine,
+		ReadTimeout:       engine.Server.ReadTimeout,
+		ReadHeaderTimeout: engine.Server.ReadHeaderTimeout,
+		WriteTimeout:      engine.Server.WriteTimeout,
+		IdleTimeout:       engine.Server.IdleTimeout,
+		MaxHeaderBytes:    engine.Server.MaxHeaderBytes,
+	}
+}
+
+// serve 记录 srv 直到 start 返回，Shutdown 导致的 http.ErrServerClosed 视为正常退出
+func (engine *Engine) serve(srv *http.Server, start func() error) error {
+	engine.debugPrintRoutes(srv.Addr)
+
+	engine.serversMu.Lock()
+	if engine.servers == nil {
+		engine.servers = make(map[*http.Server]struct{})
+	}
+	engine.servers[srv] = struct{}{}
+	engine.serversMu.Unlock()
+
+	defer func() {
+		engine.serversMu.Lock()
+		delete(engine.servers, srv)
+		engine.serversMu.Unlock()
+	}()
+
+	if err := start(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Run 在 addr 上以 HTTP 提供服务，调用 Shutdown 后返回 nil
+func (engine *Engine) Run(addr string) (err error) {
+	srv := engine.newServer(addr)
+	return engine.serve(srv, srv.ListenAndServe)
+}
+
+// RunTLS 在 addr 上以 HTTPS 提供服务
+func (engine *Engine) RunTLS(addr, certFile, keyFile string) (err error) {
+	srv := engine.newServer(addr)
+	return engine.serve(srv, func() error {
+		return srv.ListenAndServeTLS(certFile, keyFile)
+	})
+}
+
+// RunUnix 在 unix socket 文件 file 上提供服务，file 已存在且是遗留的 socket 时会先删除它
+func (engine *Engine) RunUnix(file string) (err error) {
+	if info, err := os.Lstat(file); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("gee: %s exists and is not a unix socket", file)
+		}
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+	listener, err := net.Listen("unix", file)
+	if err != nil {
+		return err
+	}
+	// UnixListener 关闭时会删除 socket 文件
+	return engine.RunListener(listener)
+}
+
+// RunListener 在已经创建好的 listener 上提供服务，适用于 systemd socket activation 等场景
+func (engine *Engine) RunListener(listener net.Listener) (err error) {
+	srv := engine.newServer(listener.Addr().String())
+	return engine.serve(srv, func() error {
+		return srv.Serve(listener)
+	})
+}
+
+// Shutdown 让所有通过 Run 系列方法启动的 server 停止接受新连接，并等待处理中的请求完成。
+// Run 系列方法会在 Shutdown 开始时立即返回，调用方应等待 Shutdown 返回后再退出进程。
+// ctx 结束时仍未完成的连接会被直接关闭，并返回 ctx.Err()。
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	engine.serversMu.Lock()
+	servers := make([]*http.Server, 0, len(engine.servers))
+	for srv := range engine.servers {
+		servers = append(servers, srv)
+	}
+	engine.serversMu.Unlock()
+
+	var first error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+				srv.Close()
+			}
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// RunGraceful 在 addr 上提供服务，收到 signals 中的信号(默认为 SIGINT 和 SIGTERM)后调用 Shutdown，
+// 最多等待 timeout 让处理中的请求完成
+func (engine *Engine) RunGraceful(addr string, timeout time.Duration, signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, signals...)
+	defer signal.Stop(quit)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- engine.Run(addr)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-quit:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := engine.Shutdown(ctx); err != nil {
+		return err
+	}
+	return <-errc
+}
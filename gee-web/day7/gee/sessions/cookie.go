@@ -0,0 +1,68 @@
+package sessions
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"time"
+
+	"Gee/gee-web/day7/gee"
+)
+
+// maxCookieSize 是浏览器普遍支持的单个 cookie 的最大长度
+const maxCookieSize = 4096
+
+var ErrCookieTooLarge = errors.New("sessions: encoded session is too large for a cookie")
+
+// CookieStore 把会话数据签名后直接保存在 cookie 中，服务端不保存任何状态。
+// 签名使用 Engine.SetCookieKeys 设置的密钥，数据对客户端可见但无法篡改。
+// 过期时间随数据一起签名，服务端会拒绝过期的 cookie，但在过期之前无法撤销：
+// 退出登录后，之前截获的 cookie 在 Options.MaxAge 内仍然有效。需要撤销会话时应使用 ServerStore。
+type CookieStore struct{}
+
+// cookieEntry 是签名保存在 cookie 中的内容
+type cookieEntry struct {
+	Values  map[string]interface{}
+	Expires time.Time
+}
+
+func NewCookieStore() *CookieStore {
+	return &CookieStore{}
+}
+
+func (s *CookieStore) Load(c *gee.Context, name string) (map[string]interface{}, error) {
+	data, err := c.SignedCookie(name)
+	if err == gee.ErrNoCookieKeys {
+		return nil, err
+	}
+	if err != nil {
+		return nil, nil
+	}
+	var entry cookieEntry
+	if err := gob.NewDecoder(bytes.NewReader([]byte(data))).Decode(&entry); err != nil {
+		return nil, nil
+	}
+	if time.Now().After(entry.Expires) {
+		return nil, nil
+	}
+	return entry.Values, nil
+}
+
+func (s *CookieStore) Save(c *gee.Context, name string, values map[string]interface{}, opts Options) error {
+	var buf bytes.Buffer
+	entry := cookieEntry{Values: values, Expires: time.Now().Add(opts.MaxAge)}
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		return err
+	}
+	// 签名后的 cookie 约为原始数据的 4/3 倍
+	if buf.Len()*4/3+64 > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+	return c.SetSignedCookie(name, buf.String(), opts.Cookie)
+}
+
+// Destroy 对 CookieStore 无需处理，下一次 Save 会覆盖整个 cookie。
+// 已经发出的 cookie 无法撤销，只能等待过期
+func (s *CookieStore) Destroy(*gee.Context, string) error {
+	return nil
+}
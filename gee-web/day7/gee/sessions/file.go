@@ -0,0 +1,115 @@
+package sessions
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const filePrefix = "session_"
+
+type fileEntry struct {
+	Values  map[string]interface{}
+	Expires time.Time
+}
+
+// FileStore 把每个会话以 gob 编码保存为 dir 下的一个文件
+type FileStore struct {
+	*ServerStore
+	dir string
+}
+
+// NewFileStore 创建文件存储，dir 不存在时会被创建
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	f := &FileStore{dir: dir}
+	f.ServerStore = NewServerStore(f)
+	return f, nil
+}
+
+func (f *FileStore) path(id string) string {
+	return filepath.Join(f.dir, filePrefix+id)
+}
+
+func (f *FileStore) readEntry(path string) (*fileEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry fileEntry
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (f *FileStore) Read(id string) (map[string]interface{}, error) {
+	entry, err := f.readEntry(f.path(id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(entry.Expires) {
+		return nil, f.Remove(id)
+	}
+	return entry.Values, nil
+}
+
+// Write 先写临时文件再重命名，避免并发读到写了一半的文件
+func (f *FileStore) Write(id string, values map[string]interface{}, maxAge time.Duration) error {
+	var buf bytes.Buffer
+	entry := fileEntry{Values: values, Expires: time.Now().Add(maxAge)}
+	if err := gob.NewEncoder(&buf).Encode(&entry); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(f.dir, "tmp_")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(buf.Bytes()); err == nil {
+		err = tmp.Close()
+	} else {
+		_ = tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path(id))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func (f *FileStore) Remove(id string) error {
+	if err := os.Remove(f.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Sweep 删除所有过期或无法解析的会话文件
+func (f *FileStore) Sweep() error {
+	files, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), filePrefix) {
+			continue
+		}
+		path := filepath.Join(f.dir, file.Name())
+		if entry, err := f.readEntry(path); err != nil || now.After(entry.Expires) {
+			_ = os.Remove(path)
+		}
+	}
+	return nil
+}
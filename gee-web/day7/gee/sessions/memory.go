@@ -0,0 +1,104 @@
+package sessions
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	values  map[string]interface{}
+	expires time.Time
+}
+
+// MemoryStore 把会话保存在进程内存中，后台 goroutine 定期清理过期的会话
+type MemoryStore struct {
+	*ServerStore
+	mu       sync.Mutex
+	sessions map[string]memoryEntry
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// NewMemoryStore 创建内存存储，每隔 sweepInterval 清理一次过期会话，sweepInterval 不大于 0 时不清理
+func NewMemoryStore(sweepInterval time.Duration) *MemoryStore {
+	m := &MemoryStore{
+		sessions: make(map[string]memoryEntry),
+		stop:     make(chan struct{}),
+	}
+	m.ServerStore = NewServerStore(m)
+	if sweepInterval > 0 {
+		go m.sweepLoop(sweepInterval)
+	}
+	return m
+}
+
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		cp[k] = v
+	}
+	return cp
+}
+
+func (m *MemoryStore) Read(id string) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.sessions[id]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, nil
+	}
+	return copyValues(entry.values), nil
+}
+
+func (m *MemoryStore) Write(id string, values map[string]interface{}, maxAge time.Duration) error {
+	m.mu.Lock()
+	m.sessions[id] = memoryEntry{values: copyValues(values), expires: time.Now().Add(maxAge)}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) Remove(id string) error {
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	return nil
+}
+
+// Sweep 删除所有过期的会话
+func (m *MemoryStore) Sweep() {
+	now := time.Now()
+	m.mu.Lock()
+	for id, entry := range m.sessions {
+		if now.After(entry.expires) {
+			delete(m.sessions, id)
+		}
+	}
+	m.mu.Unlock()
+}
+
+// Len 返回当前保存的会话数，包括已过期但尚未清理的会话
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sessions)
+}
+
+// Close 停止后台清理
+func (m *MemoryStore) Close() {
+	m.once.Do(func() {
+		close(m.stop)
+	})
+}
+
+func (m *MemoryStore) sweepLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.Sweep()
+		case <-m.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package sessions
+
+import (
+	"net/http"
+	"time"
+
+	"Gee/gee-web/day7/gee"
+)
+
+// 会话中间件，处理函数通过 Default(c) 取得会话，只需要关心会话中的数据：
+//
+//	r.Use(sessions.Sessions("gee_session", sessions.NewMemoryStore(time.Minute), sessions.Options{}))
+//	r.POST("/login", func(c *gee.Context) {
+//		s := sessions.Default(c)
+//		_ = s.Regenerate()
+//		s.Set("user", "geektutu")
+//		_ = s.Save()
+//	})
+//
+// 会话数据使用 encoding/gob 编码，保存自定义类型前需要调用 gob.Register。
+
+const (
+	defaultKey = "gee/sessions"
+	sessionKey = "gee/sessions/"
+)
+
+// Store 负责会话数据的读写，以及在响应中写入对应的 cookie
+type Store interface {
+	// Load 读取当前请求中名为 name 的会话，会话不存在、已过期或无效时返回 nil, nil
+	Load(c *gee.Context, name string) (map[string]interface{}, error)
+	// Save 保存会话数据并在响应中写入 cookie，必须在写响应体之前调用
+	Save(c *gee.Context, name string, values map[string]interface{}, opts Options) error
+	// Destroy 删除当前会话在服务端的数据，之后的 Save 会开启一个新的会话
+	Destroy(c *gee.Context, name string) error
+}
+
+// Options 为会话的有效期和 cookie 属性，零值字段使用默认值
+type Options struct {
+	MaxAge time.Duration // 默认 24 小时
+	Cookie gee.CookieOptions
+}
+
+func (opts Options) withDefaults() Options {
+	if opts.MaxAge == 0 {
+		opts.MaxAge = 24 * time.Hour
+	}
+	if opts.Cookie.SameSite == 0 {
+		opts.Cookie.SameSite = http.SameSiteLaxMode
+	}
+	opts.Cookie.HttpOnly = true
+	opts.Cookie.MaxAge = int(opts.MaxAge / time.Second)
+	return opts
+}
+
+// Session 是一个请求内的会话，数据在第一次访问时才从 Store 中读取
+type Session struct {
+	name   string
+	store  Store
+	opts   Options
+	c      *gee.Context
+	values map[string]interface{}
+	err    error
+}
+
+// Sessions 返回管理名为 name 的会话的中间件，name 同时也是 cookie 的名字
+func Sessions(name string, store Store, opts Options) gee.HandlerFunc {
+	opts = opts.withDefaults()
+	return func(c *gee.Context) {
+		s := &Session{name: name, store: store, opts: opts, c: c}
+		c.Set(sessionKey+name, s)
+		c.Set(defaultKey, s)
+		c.Next()
+	}
+}
+
+// Default 返回最后一个 Sessions 中间件创建的会话
+func Default(c *gee.Context) *Session {
+	return c.MustGet(defaultKey).(*Session)
+}
+
+// Named 返回名为 name 的会话
+func Named(c *gee.Context, name string) *Session {
+	return c.MustGet(sessionKey + name).(*Session)
+}
+
+func (s *Session) load() {
+	if s.values != nil {
+		return
+	}
+	s.values, s.err = s.store.Load(s.c, s.name)
+	if s.values == nil {
+		s.values = make(map[string]interface{})
+	}
+}
+
+func (s *Session) Get(key string) interface{} {
+	s.load()
+	return s.values[key]
+}
+
+func (s *Session) Set(key string, value interface{}) {
+	s.load()
+	s.values[key] = value
+}
+
+func (s *Session) Delete(key string) {
+	s.load()
+	delete(s.values, key)
+}
+
+// Clear 删除会话中的所有数据
+func (s *Session) Clear() {
+	s.load()
+	for key := range s.values {
+		delete(s.values, key)
+	}
+}
+
+// Save 保存会话，必须在写响应体之前调用
+func (s *Session) Save() error {
+	s.load()
+	if s.err != nil {
+		return s.err
+	}
+	return s.store.Save(s.c, s.name, s.values, s.opts)
+}
+
+// Regenerate 保留会话数据但更换会话 id，登录等权限变化时调用以防止会话固定攻击，之后需要调用 Save
+func (s *Session) Regenerate() error {
+	s.load()
+	return s.store.Destroy(s.c, s.name)
+}
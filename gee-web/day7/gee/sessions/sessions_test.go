@@ -0,0 +1,148 @@
+package sessions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Gee/gee-web/day7/gee"
+)
+
+func newSessionEngine(store Store) *gee.Engine {
+	r := gee.New()
+	r.SetCookieKeys([]byte("secret"))
+	r.Use(Sessions("gee_session", store, Options{}))
+	r.POST("/login", func(c *gee.Context) {
+		s := Default(c)
+		if err := s.Regenerate(); err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.Set("user", c.Query("user"))
+		s.Set("visits", 0)
+		if err := s.Save(); err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/me", func(c *gee.Context) {
+		s := Default(c)
+		user, _ := s.Get("user").(string)
+		if user == "" {
+			c.Fail(http.StatusUnauthorized, "login required")
+			return
+		}
+		visits := s.Get("visits").(int) + 1
+		s.Set("visits", visits)
+		_ = s.Save()
+		c.String(http.StatusOK, "%s %d", user, visits)
+	})
+	r.POST("/logout", func(c *gee.Context) {
+		s := Default(c)
+		s.Clear()
+		_ = s.Regenerate()
+		_ = s.Save()
+	})
+	return r
+}
+
+func do(r *gee.Engine, method, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func testStore(t *testing.T, store Store) {
+	r := newSessionEngine(store)
+	if w := do(r, http.MethodGet, "/me", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expect 401 without session, got %d", w.Code)
+	}
+
+	login := do(r, http.MethodPost, "/login?user=geektutu", nil).Result().Cookies()
+	if len(login) != 1 || !login[0].HttpOnly {
+		t.Fatalf("unexpected session cookie %v", login)
+	}
+	w := do(r, http.MethodGet, "/me", login)
+	if w.Body.String() != "geektutu 1" {
+		t.Fatalf("unexpected response %q", w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	if w = do(r, http.MethodGet, "/me", cookies); w.Body.String() != "geektutu 2" {
+		t.Fatalf("unexpected response %q", w.Body.String())
+	}
+
+	// 再次登录会更换会话 id
+	relogin := do(r, http.MethodPost, "/login?user=gee", cookies).Result().Cookies()
+	if relogin[0].Value == cookies[0].Value {
+		t.Fatal("session id should change after Regenerate")
+	}
+
+	logout := do(r, http.MethodPost, "/logout", relogin).Result().Cookies()
+	if w = do(r, http.MethodGet, "/me", logout); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expect 401 after logout, got %d", w.Code)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	testStore(t, store)
+
+	_ = store.Write("expired", map[string]interface{}{"user": "gee"}, -time.Second)
+	if values, _ := store.Read("expired"); values != nil {
+		t.Fatal("expired session shouldn't be returned")
+	}
+	size := store.Len()
+	store.Sweep()
+	if store.Len() != size-1 {
+		t.Fatalf("expired session should be swept, %d -> %d", size, store.Len())
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+}
+
+func TestCookieStore(t *testing.T) {
+	store := NewCookieStore()
+	testStore(t, store)
+
+	r := newSessionEngine(store)
+	login := do(r, http.MethodPost, "/login?user=geektutu", nil).Result().Cookies()
+	login[0].Value = "x" + login[0].Value
+	if w := do(r, http.MethodGet, "/me", login); w.Code != http.StatusUnauthorized {
+		t.Fatalf("tampered session cookie should be rejected, got %d", w.Code)
+	}
+
+	// 过期时间随数据签名，过期的 cookie 即使签名正确也会被拒绝
+	r.POST("/expired", func(c *gee.Context) {
+		values := map[string]interface{}{"user": "geektutu", "visits": 0}
+		_ = store.Save(c, "gee_session", values, Options{MaxAge: -time.Second})
+	})
+	expired := do(r, http.MethodPost, "/expired", nil).Result().Cookies()
+	if len(expired) != 1 {
+		t.Fatalf("unexpected session cookie %v", expired)
+	}
+	if w := do(r, http.MethodGet, "/me", expired); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expired session cookie should be rejected, got %d", w.Code)
+	}
+}
+
+func TestInvalidID(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	r := newSessionEngine(store)
+	cookie := &http.Cookie{Name: "gee_session", Value: "../../etc/passwd"}
+	if w := do(r, http.MethodGet, "/me", []*http.Cookie{cookie}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("invalid session id should be ignored, got %d", w.Code)
+	}
+}
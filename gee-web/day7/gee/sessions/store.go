@@ -0,0 +1,92 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"Gee/gee-web/day7/gee"
+)
+
+// Backend 是以会话 id 为键的服务端存储，配合 ServerStore 使用
+type Backend interface {
+	// Read 返回 id 对应的数据，不存在或已过期时返回 nil, nil
+	Read(id string) (map[string]interface{}, error)
+	Write(id string, values map[string]interface{}, maxAge time.Duration) error
+	Remove(id string) error
+}
+
+// ServerStore 把数据保存在 Backend 中，cookie 中只保存随机生成的会话 id
+type ServerStore struct {
+	backend Backend
+}
+
+func NewServerStore(backend Backend) *ServerStore {
+	return &ServerStore{backend: backend}
+}
+
+func idKey(name string) string {
+	return "gee/sessions/id/" + name
+}
+
+func (s *ServerStore) Load(c *gee.Context, name string) (map[string]interface{}, error) {
+	id, err := c.Cookie(name)
+	if err != nil || !validID(id) {
+		return nil, nil
+	}
+	values, err := s.backend.Read(id)
+	if err != nil || values == nil {
+		return nil, err
+	}
+	c.Set(idKey(name), id)
+	return values, nil
+}
+
+func (s *ServerStore) Save(c *gee.Context, name string, values map[string]interface{}, opts Options) error {
+	id := c.GetString(idKey(name))
+	if id == "" {
+		var err error
+		if id, err = newID(); err != nil {
+			return err
+		}
+		c.Set(idKey(name), id)
+	}
+	if err := s.backend.Write(id, values, opts.MaxAge); err != nil {
+		return err
+	}
+	c.SetCookie(name, id, opts.Cookie)
+	return nil
+}
+
+func (s *ServerStore) Destroy(c *gee.Context, name string) error {
+	id := c.GetString(idKey(name))
+	if id == "" {
+		return nil
+	}
+	c.Set(idKey(name), "")
+	return s.backend.Remove(id)
+}
+
+const idLength = 32
+
+func newID() (string, error) {
+	b := make([]byte, idLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// validID 只接受 newID 生成的格式，避免客户端构造的 id 被用作文件路径
+func validID(id string) bool {
+	if len(id) != base64.RawURLEncoding.EncodedLen(idLength) {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		ch := id[i]
+		if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '-' || ch == '_') {
+			return false
+		}
+	}
+	return true
+}
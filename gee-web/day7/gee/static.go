@@ -0,0 +1,186 @@
+package gee
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// StaticConfig 是 StaticFSWithConfig 的配置
+type StaticConfig struct {
+	// 访问目录时返回的文件，默认为 "index.html"
+	Index string
+	// 目录中没有 Index 文件时列出目录内容，默认关闭
+	Browse bool
+	// 文件不存在时返回的文件(相对于根目录)，用于前端路由的单页应用，为空时返回 404
+	SPAFallback string
+	// 设置 Cache-Control 响应头，例如 "public, max-age=86400"，为空时不设置
+	CacheControl string
+	// 根据修改时间和大小生成弱 ETag，并处理 If-None-Match
+	ETag bool
+}
+
+// StaticFS 以 fs 中的文件响应 relativePath 下的请求，文件不存在时执行 NoRoute 的处理函数
+func (group *RouterGroup) StaticFS(relativePath string, fs http.FileSystem) {
+	group.StaticFSWithConfig(relativePath, fs, StaticConfig{})
+}
+
+// StaticFSWithConfig 同 StaticFS，config 控制目录、单页应用和缓存相关的行为
+func (group *RouterGroup) StaticFSWithConfig(relativePath string, fs http.FileSystem, config StaticConfig) {
+	if strings.ContainsAny(relativePath, ":*") {
+		panic("URL parameters can not be used when serving a static folder")
+	}
+	if config.Index == "" {
+		config.Index = "index.html"
+	}
+	handler := group.createStaticHandler(fs, config)
+	group.GET(path.Join(relativePath, "/*filepath"), handler)
+	// "/*filepath" 不匹配根目录本身，已经注册了根目录的路由时(例如 r.GET("/", home))保留原来的路由
+	dir := strings.TrimSuffix(relativePath, "/") + "/"
+	if !group.engine.router.registered(http.MethodGet, group.prefix+dir) {
+		group.GET(dir, handler)
+	}
+}
+
+// StaticEmbed 以嵌入的文件响应 relativePath 下的请求，root 为文件在 fsys 中所在的目录
+//
+//	//go:embed public
+//	var public embed.FS
+//
+//	r.StaticEmbed("/assets", public, "public")
+func (group *RouterGroup) StaticEmbed(relativePath string, fsys fs.FS, root string) {
+	sub, err := fs.Sub(fsys, root)
+	if err != nil {
+		panic(err)
+	}
+	group.StaticFS(relativePath, http.FS(sub))
+}
+
+// StaticFile 以文件 filepath 响应 relativePath 的请求
+func (group *RouterGroup) StaticFile(relativePath, filepath string) {
+	if strings.ContainsAny(relativePath, ":*") {
+		panic("URL parameters can not be used when serving a static file")
+	}
+	group.GET(relativePath, func(c *Context) {
+		c.File(filepath)
+	})
+}
+
+func (group *RouterGroup) createStaticHandler(fs http.FileSystem, config StaticConfig) HandlerFunc {
+	return func(c *Context) {
+		name := path.Clean("/" + c.Param("filepath"))
+		if serveStatic(c, fs, name, config) {
+			return
+		}
+		if config.SPAFallback != "" && serveStatic(c, fs, path.Clean("/"+config.SPAFallback), config) {
+			return
+		}
+		c.serveNoRoute()
+	}
+}
+
+// serveStatic 输出 fs 中的 name，文件不存在或者是不能访问的目录时返回 false
+func serveStatic(c *Context, fs http.FileSystem, name string, config StaticConfig) bool {
+	f, err := fs.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	if info.IsDir() {
+		index, err := fs.Open(path.Join(name, config.Index))
+		if err == nil {
+			defer index.Close()
+			if indexInfo, err := index.Stat(); err == nil && !indexInfo.IsDir() {
+				f, info = index, indexInfo
+			}
+		}
+		if !info.IsDir() || config.Browse {
+			// 目录的地址需要以 '/' 结尾，否则页面中的相对路径会出错
+			if urlPath := c.Req.URL.Path; !strings.HasSuffix(urlPath, "/") {
+				location := path.Base(urlPath) + "/"
+				if q := c.Req.URL.RawQuery; q != "" {
+					location += "?" + q
+				}
+				c.Redirect(http.StatusMovedPermanently, location)
+				return true
+			}
+		}
+		if info.IsDir() {
+			if !config.Browse {
+				return false
+			}
+			return dirList(c, f)
+		}
+	}
+
+	header := c.Writer.Header()
+	if config.CacheControl != "" {
+		header.Set("Cache-Control", config.CacheControl)
+	}
+	if config.ETag {
+		header.Set("ETag", fmt.Sprintf(`W/"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+	}
+	http.ServeContent(c.Writer, c.Req, info.Name(), info.ModTime(), f)
+	return true
+}
+
+var dirListTemplate = template.Must(template.New("dir").Parse(`<!doctype html>
+<meta name="viewport" content="width=device-width">
+<pre>
+{{range .}}<a href="{{.URL}}">{{.Name}}</a>
+{{end}}</pre>
+`))
+
+// dirList 列出目录内容，与 http.FileServer 的格式类似
+func dirList(c *Context, dir http.File) bool {
+	entries, err := dir.Readdir(-1)
+	if err != nil {
+		return false
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	type item struct {
+		Name string
+		URL  string
+	}
+	items := make([]item, len(entries))
+	for i, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		items[i] = item{Name: name, URL: (&url.URL{Path: name}).String()}
+	}
+	c.Render(http.StatusOK, HTML{Template: dirListTemplate, Name: "dir", Data: items})
+	return true
+}
+
+// File 以文件 filepath 响应请求，支持 Range 和 If-Modified-Since
+func (c *Context) File(filepath string) {
+	http.ServeFile(c.Writer, c.Req, filepath)
+}
+
+// serveNoRoute 在已匹配的路由中执行 NoRoute 设置的处理函数，用于静态文件不存在等情况。
+// 外层中间件的 Next 仍在遍历原来的处理链，因此 NoRoute 的处理链使用自己的 index 执行，
+// 其中的中间件调用 Next 时与普通的 404 行为一致，执行完后恢复原来的处理链并中止后续的处理函数
+func (c *Context) serveNoRoute() {
+	if len(c.engine.noRoute) == 0 {
+		notFound(c)
+		c.Abort()
+		return
+	}
+	handlers, index := c.handlers, c.index
+	c.handlers, c.index = c.engine.noRoute, -1
+	c.Next()
+	c.handlers, c.index = handlers, index
+	c.Abort()
+}
@@ -0,0 +1,194 @@
+package gee
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newStaticDir(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":    "<h1>home</h1>",
+		"app.js":        "console.log('gee')",
+		"docs/a.txt":    "a",
+		"docs/b&c.txt":  "b",
+		"favicon.ico":   "icon",
+		"nested/x.html": "x",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestStatic(t *testing.T) {
+	dir := newStaticDir(t)
+	r := New()
+	r.NoRoute(func(c *Context) {
+		c.String(http.StatusNotFound, "custom 404")
+	})
+	r.Static("/assets", dir)
+	r.StaticFile("/favicon.ico", filepath.Join(dir, "favicon.ico"))
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/assets/app.js", http.StatusOK, "console.log('gee')"},
+		{"/assets/", http.StatusOK, "<h1>home</h1>"},
+		{"/assets/missing.js", http.StatusNotFound, "custom 404"},
+		{"/assets/docs/", http.StatusNotFound, "custom 404"},
+		{"/assets/../../etc/passwd", http.StatusNotFound, "custom 404"},
+		{"/favicon.ico", http.StatusOK, "icon"},
+	}
+	for _, tc := range cases {
+		w := performRequest(r, http.MethodGet, tc.path)
+		if w.Code != tc.code || w.Body.String() != tc.body {
+			t.Errorf("%s: expect %d %q, got %d %q", tc.path, tc.code, tc.body, w.Code, w.Body.String())
+		}
+	}
+	if w := performRequest(r, http.MethodGet, "/assets/app.js"); w.Header().Get("Cache-Control") != "" || w.Header().Get("ETag") != "" {
+		t.Fatalf("cache headers should not be set by default, got %v", w.Header())
+	}
+}
+
+func TestStaticNoRouteWithMiddleware(t *testing.T) {
+	dir := newStaticDir(t)
+	r := New()
+	var trace []string
+	r.Use(func(c *Context) {
+		c.Next()
+		trace = append(trace, "outer")
+	}, func(c *Context) {
+		c.Next()
+		trace = append(trace, "inner")
+	})
+	r.NoRoute(func(c *Context) {
+		trace = append(trace, "mw-before")
+		c.Next()
+		trace = append(trace, "mw-after")
+	}, func(c *Context) {
+		trace = append(trace, "h")
+		c.String(http.StatusNotFound, "custom 404")
+	})
+	r.Static("/assets", dir)
+
+	// 静态文件不存在与普通的 404 中，NoRoute 的中间件行为一致
+	for _, path := range []string{"/assets/missing.txt", "/missing"} {
+		trace = nil
+		w := performRequest(r, http.MethodGet, path)
+		if w.Code != http.StatusNotFound || w.Body.String() != "custom 404" {
+			t.Fatalf("%s: expect custom 404, got %d %q", path, w.Code, w.Body.String())
+		}
+		if got := strings.Join(trace, ","); got != "mw-before,h,mw-after,inner,outer" {
+			t.Fatalf("%s: unexpected trace %s", path, got)
+		}
+	}
+}
+
+func TestStaticRootWithHandler(t *testing.T) {
+	dir := newStaticDir(t)
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "home")
+	})
+	r.StaticFS("/", http.Dir(dir))
+
+	if w := performRequest(r, http.MethodGet, "/"); w.Body.String() != "home" {
+		t.Fatalf("existing handler for / should be kept, got %q", w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/app.js"); w.Body.String() != "console.log('gee')" {
+		t.Fatalf("expect static file, got %q", w.Body.String())
+	}
+}
+
+func TestStaticBrowseAndCache(t *testing.T) {
+	dir := newStaticDir(t)
+	r := New()
+	r.StaticFSWithConfig("/files", http.Dir(dir), StaticConfig{
+		Browse:       true,
+		CacheControl: "public, max-age=60",
+		ETag:         true,
+	})
+
+	w := performRequest(r, http.MethodGet, "/files/docs/")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<a href="a.txt">a.txt</a>`) ||
+		!strings.Contains(w.Body.String(), `<a href="b&amp;c.txt">b&amp;c.txt</a>`) {
+		t.Fatalf("expect directory listing, got %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(r, http.MethodGet, "/files/docs?x=1")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/files/docs/?x=1" {
+		t.Fatalf("expect redirect to docs/, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = performRequest(r, http.MethodGet, "/files/app.js")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "public, max-age=60" || !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("expect cache headers, got %d %v", w.Code, w.Header())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/files/app.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("expect 304, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestStaticSPAFallback(t *testing.T) {
+	dir := newStaticDir(t)
+	r := New()
+	r.GET("/api/ping", func(c *Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	r.StaticFSWithConfig("/", http.Dir(dir), StaticConfig{SPAFallback: "index.html"})
+
+	cases := map[string]string{
+		"/api/ping":      "pong",
+		"/":              "<h1>home</h1>",
+		"/app.js":        "console.log('gee')",
+		"/users/42/edit": "<h1>home</h1>",
+		"/nested/":       "<h1>home</h1>",
+	}
+	for path, body := range cases {
+		w := performRequest(r, http.MethodGet, path)
+		if w.Code != http.StatusOK || w.Body.String() != body {
+			t.Errorf("%s: expect 200 %q, got %d %q", path, body, w.Code, w.Body.String())
+		}
+	}
+	if ct := performRequest(r, http.MethodGet, "/users/42").Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("fallback should be served as html, got %q", ct)
+	}
+}
+
+func TestStaticEmbed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"public/index.html": {Data: []byte("embedded index")},
+		"public/css/a.css":  {Data: []byte("body{}")},
+	}
+	r := New()
+	r.StaticEmbed("/static", fsys, "public")
+
+	if w := performRequest(r, http.MethodGet, "/static/css/a.css"); w.Code != http.StatusOK || w.Body.String() != "body{}" {
+		t.Fatalf("expect embedded file, got %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/static/"); w.Body.String() != "embedded index" {
+		t.Fatalf("expect embedded index, got %q", w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/static/public/index.html"); w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404 outside root, got %d", w.Code)
+	}
+}
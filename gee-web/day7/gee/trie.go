@@ -2,18 +2,63 @@ package gee
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
+// 压缩前缀树(radix tree)，匹配优先级: 静态 > 带约束的参数(:name<int>) > 参数(:name) > 通配(*name)。
+// 某一分支匹配失败时会回溯尝试优先级更低的分支。
+
+// Param 是一个路由参数，Key 为参数名，Value 为请求路径中对应的值
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params 按路由中出现的顺序保存参数
+type Params []Param
+
+// Get 返回第一个名为 name 的参数的值
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ByName 同 Get，参数不存在时返回空字符串
+func (ps Params) ByName(name string) string {
+	value, _ := ps.Get(name)
+	return value
+}
+
+type nodeType uint8
+
+const (
+	static nodeType = iota
+	param
+	catchAll
+)
+
 type node struct {
-	pattern  string
-	part     string
-	children []*node
-	isWild   bool // 是否精确匹配
+	pattern      string   // 完整路由，只有路由终点才非空
+	path         string   // 静态节点为压缩后的前缀，参数节点为 ":name" 或 ":name<约束>"，通配节点为 "*name"
+	typ          nodeType // 节点类型
+	indices      string   // 静态子节点 path 的首字节，与 children 一一对应
+	children     []*node  // 静态子节点
+	wildChildren []*node  // 参数子节点，带约束的按注册顺序排在前面，不带约束的最多一个且在最后
+	catchAll     *node    // 通配子节点
+
+	key        string            // 参数名
+	constraint func(string) bool // 参数约束，nil 表示匹配任意非空段
+
+	handlers []HandlerFunc // 路由的完整处理链(全局中间件 + 分组中间件 + 路由处理函数)
 }
 
 func (n *node) String() string {
-	return fmt.Sprintf("node{pattern=%s}, part=%s, isWild=%t", n.pattern, n.part, n.isWild)
+	return fmt.Sprintf("node{pattern=%s}, path=%s, type=%d", n.pattern, n.path, n.typ)
 }
 
 func (n *node) travel(list *([]*node)) {
@@ -23,55 +68,304 @@ func (n *node) travel(list *([]*node)) {
 	for _, child := range n.children {
 		child.travel(list)
 	}
+	for _, child := range n.wildChildren {
+		child.travel(list)
+	}
+	if n.catchAll != nil {
+		n.catchAll.travel(list)
+	}
 }
 
-func (n *node) matchChild(part string) *node {
-	for _, child := range n.children {
-		if child.part == part || child.isWild {
-			return child
+func longestCommonPrefix(a, b string) int {
+	i := 0
+	max := len(a)
+	if len(b) < max {
+		max = len(b)
+	}
+	for i < max && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// nextWildcard 返回 path 中下一个位于段首的通配符位置，pos 为 path 在完整路由中的偏移
+func nextWildcard(full string, pos int) int {
+	for i := pos; i < len(full); i++ {
+		if (full[i] == ':' || full[i] == '*') && i > 0 && full[i-1] == '/' {
+			return i - pos
 		}
 	}
-	return nil
+	return -1
 }
 
-func (n *node) matchChildren(part string) []*node {
-	nodes := make([]*node, 0)
-	for _, child := range n.children {
-		if child.part == part || child.isWild {
-			nodes = append(nodes, child)
+// paramEnd 返回以 ':' 开头的 path 中参数的结束位置，约束中的 '<' '>' 可以嵌套
+func paramEnd(path string) int {
+	depth := 0
+	for i := 1; i < len(path); i++ {
+		switch path[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case '/':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(path)
+}
+
+// paramConstraints 是内置的参数约束，其他约束按正则表达式处理
+var paramConstraints = map[string]func(string) bool{
+	"int": func(s string) bool {
+		if s != "" && s[0] == '-' {
+			s = s[1:]
+		}
+		return isDigits(s)
+	},
+	"uint":  isDigits,
+	"alpha": func(s string) bool { return isASCII(s, false) },
+	"alnum": func(s string) bool { return isASCII(s, true) },
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCII(s string, digits bool) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20 // 转为小写
+		if !(c >= 'a' && c <= 'z') && !(digits && s[i] >= '0' && s[i] <= '9') {
+			return false
 		}
 	}
-	return nodes
+	return s != ""
+}
+
+// newParamNode 解析 ":name" 或 ":name<约束>"
+func newParamNode(name string, pattern string) *node {
+	n := &node{path: name, typ: param, key: name[1:]}
+	i := strings.IndexByte(name, '<')
+	if i < 0 {
+		return n
+	}
+	if name[len(name)-1] != '>' || i == 1 || i == len(name)-2 {
+		panic(fmt.Sprintf("invalid parameter constraint '%s' in pattern '%s'", name, pattern))
+	}
+	if strings.IndexByte(name, '/') >= 0 {
+		panic(fmt.Sprintf("parameter constraint '%s' in pattern '%s' must not contain '/'", name, pattern))
+	}
+	n.key = name[1:i]
+	expr := name[i+1 : len(name)-1]
+	if match, ok := paramConstraints[expr]; ok {
+		n.constraint = match
+		return n
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("invalid parameter constraint '%s' in pattern '%s': %v", name, pattern, err))
+	}
+	n.constraint = re.MatchString
+	return n
 }
 
-func (n *node) insert(pattern string, parts []string, height int) {
-	if len(parts) == height {
-		n.pattern = pattern
-		return
+// addWildChild 返回 path 为 name 的参数子节点，不存在时创建
+func (n *node) addWildChild(name string, pattern string) *node {
+	for _, child := range n.wildChildren {
+		if child.path == name {
+			return child
+		}
 	}
-	part := parts[height]
-	child := n.matchChild(part)
-	if child == nil {
-		child = &node{part: part, isWild: part[0] == ':' || part[0] == '*'}
+	child := newParamNode(name, pattern)
+	for _, other := range n.wildChildren {
+		// 约束相同而名字不同的参数无法区分
+		if other.path[len(other.key)+1:] == child.path[len(child.key)+1:] {
+			panic(fmt.Sprintf("'%s' in pattern '%s' conflicts with existing wildcard '%s'", name, pattern, other.path))
+		}
+	}
+	last := len(n.wildChildren) - 1
+	if child.constraint != nil && last >= 0 && n.wildChildren[last].constraint == nil {
+		n.wildChildren = append(n.wildChildren[:last], child, n.wildChildren[last])
+	} else {
+		n.wildChildren = append(n.wildChildren, child)
+	}
+	return child
+}
+
+// insert 把 pattern 插入到以 n 为根的树中，返回路由终点所在的节点
+func (n *node) insert(pattern string) *node {
+	pos := 0
+	for pos < len(pattern) {
+		path := pattern[pos:]
+		if pos > 0 && pattern[pos-1] == '/' && (path[0] == ':' || path[0] == '*') {
+			end := paramEnd(path)
+			name := path[:end]
+			if path[0] == '*' {
+				if end != len(path) {
+					panic(fmt.Sprintf("catch-all '%s' must be at the end of pattern '%s'", name, pattern))
+				}
+				if n.catchAll == nil {
+					n.catchAll = &node{path: name, typ: catchAll, key: name[1:]}
+				} else if n.catchAll.path != name {
+					panic(fmt.Sprintf("'%s' in pattern '%s' conflicts with existing catch-all '%s'", name, pattern, n.catchAll.path))
+				}
+				return n.catchAll
+			}
+			if len(name) < 2 || name[1] == '<' {
+				panic(fmt.Sprintf("wildcard must be named in pattern '%s'", pattern))
+			}
+			n = n.addWildChild(name, pattern)
+			pos += end
+			continue
+		}
+
+		if i := strings.IndexByte(n.indices, path[0]); i >= 0 {
+			child := n.children[i]
+			common := longestCommonPrefix(child.path, path)
+			if common < len(child.path) {
+				// 拆分已有节点，公共前缀成为新的父节点
+				split := &node{
+					path:     child.path[:common],
+					typ:      static,
+					indices:  child.path[common : common+1],
+					children: []*node{child},
+				}
+				child.path = child.path[common:]
+				n.children[i] = split
+				child = split
+			}
+			n = child
+			pos += common
+			continue
+		}
+
+		// 新建静态节点，一直延伸到下一个通配符之前
+		end := nextWildcard(pattern, pos)
+		if end < 0 {
+			end = len(path)
+		}
+		child := &node{path: path[:end], typ: static}
+		n.indices += path[:1]
 		n.children = append(n.children, child)
+		n = child
+		pos += end
+	}
+	return n
+}
+
+// lookup 沿静态节点精确查找不含通配符的 pattern，返回对应的节点，不存在时返回 nil
+func (n *node) lookup(pattern string) *node {
+	for {
+		if !strings.HasPrefix(pattern, n.path) {
+			return nil
+		}
+		pattern = pattern[len(n.path):]
+		if pattern == "" {
+			return n
+		}
+		i := strings.IndexByte(n.indices, pattern[0])
+		if i < 0 {
+			return nil
+		}
+		n = n.children[i]
+	}
+}
+
+// search 在以 n 为根的树中查找 path，匹配到的参数写入 params
+func (n *node) search(path string, params *Params) *node {
+	if !strings.HasPrefix(path, n.path) {
+		return nil
 	}
-	child.insert(pattern, parts, height+1)
+	return n.searchChildren(path[len(n.path):], params)
 }
 
-func (n *node) search(parts []string, height int) *node {
-	if len(parts) == height || strings.HasPrefix(n.part, "*") {
+func (n *node) searchChildren(path string, params *Params) *node {
+	if path == "" {
 		if n.pattern == "" {
 			return nil
 		}
 		return n
 	}
-	part := parts[height]
-	children := n.matchChildren(part)
-	for _, child := range children {
-		result := child.search(parts, height+1)
-		if result != nil {
+	if i := strings.IndexByte(n.indices, path[0]); i >= 0 {
+		if result := n.children[i].search(path, params); result != nil {
 			return result
 		}
 	}
+	if len(n.wildChildren) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			segment := path[:end]
+			for _, child := range n.wildChildren {
+				if child.constraint != nil && !child.constraint(segment) {
+					continue
+				}
+				size := len(*params)
+				*params = append(*params, Param{Key: child.key, Value: segment})
+				if result := child.searchChildren(path[end:], params); result != nil {
+					return result
+				}
+				*params = (*params)[:size]
+			}
+		}
+	}
+	if child := n.catchAll; child != nil && child.pattern != "" {
+		if child.key != "" {
+			*params = append(*params, Param{Key: child.key, Value: path})
+		}
+		return child
+	}
 	return nil
 }
+
+// searchCaseInsensitive 忽略大小写查找 path，找到时返回追加到 buf 后的、路由中实际的路径写法
+func (n *node) searchCaseInsensitive(path string, buf []byte) ([]byte, bool) {
+	if len(path) < len(n.path) || !strings.EqualFold(path[:len(n.path)], n.path) {
+		return nil, false
+	}
+	buf = append(buf, n.path...)
+	return n.searchChildrenCaseInsensitive(path[len(n.path):], buf)
+}
+
+func (n *node) searchChildrenCaseInsensitive(path string, buf []byte) ([]byte, bool) {
+	if path == "" {
+		return buf, n.pattern != ""
+	}
+	for _, child := range n.children {
+		if result, ok := child.searchCaseInsensitive(path, buf); ok {
+			return result, true
+		}
+	}
+	if len(n.wildChildren) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			segment := path[:end]
+			for _, child := range n.wildChildren {
+				if child.constraint != nil && !child.constraint(segment) {
+					continue
+				}
+				if result, ok := child.searchChildrenCaseInsensitive(path[end:], append(buf, segment...)); ok {
+					return result, true
+				}
+			}
+		}
+	}
+	if child := n.catchAll; child != nil && child.pattern != "" {
+		return append(buf, path...), true
+	}
+	return nil, false
+}
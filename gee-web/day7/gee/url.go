@@ -0,0 +1,97 @@
+package gee
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Name 为路由命名，名字在 Engine 中必须唯一
+//
+//	r.GET("/users/:id<int>", showUser).Name("user.show")
+//	r.URL("user.show", 42) // "/users/42"
+func (r *Route) Name(name string) *Route {
+	engine := r.group.engine
+	if other, ok := engine.namedRoutes[name]; ok && other != r {
+		panic(fmt.Sprintf("route name '%s' is already used by '%s %s'", name, other.method, other.pattern))
+	}
+	if engine.namedRoutes == nil {
+		engine.namedRoutes = make(map[string]*Route)
+	}
+	if r.name != "" {
+		delete(engine.namedRoutes, r.name)
+	}
+	r.name = name
+	engine.namedRoutes[name] = r
+	return r
+}
+
+// URL 按顺序用 params 填充路由中的参数并返回路径，参数值会被转义，:name<约束> 的值必须满足约束。
+// 省略末尾的可选参数时，对应的段也会被省略。
+func (r *Route) URL(params ...interface{}) (string, error) {
+	segments := strings.Split(r.pattern, "/")[1:]
+	var buf strings.Builder
+	i := 0
+	for _, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			buf.WriteString("/" + seg)
+			continue
+		}
+		n := r.params[i]
+		optional := seg[0] == ':' && seg[len(seg)-1] == '?'
+		if i >= len(params) {
+			if optional {
+				break
+			}
+			return "", fmt.Errorf("gee: missing value for '%s' in route '%s'", seg, r.pattern)
+		}
+		value := fmt.Sprint(params[i])
+		i++
+		if seg[0] == '*' {
+			parts := strings.Split(strings.TrimPrefix(value, "/"), "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			buf.WriteString("/" + strings.Join(parts, "/"))
+			continue
+		}
+		if value == "" || (n.constraint != nil && !n.constraint(value)) {
+			return "", fmt.Errorf("gee: value %q does not match '%s' in route '%s'", value, n.path, r.pattern)
+		}
+		buf.WriteString("/" + url.PathEscape(value))
+	}
+	if i < len(params) {
+		return "", fmt.Errorf("gee: too many values for route '%s'", r.pattern)
+	}
+	if buf.Len() == 0 {
+		return "/", nil
+	}
+	return buf.String(), nil
+}
+
+// routeParams 在注册路由时解析 pattern 中的参数，避免每次生成 URL 都重新编译约束
+func routeParams(pattern string) []*node {
+	var params []*node
+	for _, seg := range strings.Split(pattern, "/") {
+		if seg == "" {
+			continue
+		}
+		switch seg[0] {
+		case ':':
+			params = append(params, newParamNode(strings.TrimSuffix(seg, "?"), pattern))
+		case '*':
+			params = append(params, nil)
+		}
+	}
+	return params
+}
+
+// URL 返回名为 name 的路由填充参数后的路径，见 Route.URL。
+// 模板中可以通过内置的 url 函数调用：{{ url "user.show" .ID }}
+func (engine *Engine) URL(name string, params ...interface{}) (string, error) {
+	r, ok := engine.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("gee: route named '%s' is not defined", name)
+	}
+	return r.URL(params...)
+}
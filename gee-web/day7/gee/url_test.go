@@ -0,0 +1,82 @@
+package gee
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	r := New()
+	v1 := r.Group("/v1")
+	v1.GET("/users/:id<int>", func(c *Context) {}).Name("user.show")
+	v1.GET("/files/*filepath", func(c *Context) {}).Name("file")
+	v1.GET("/archive/:year<uint>?/:month<uint>?", func(c *Context) {}).Name("archive")
+	r.GET("/search/:q/", func(c *Context) {}).Name("search")
+	r.GET("/", func(c *Context) {}).Name("home")
+
+	cases := []struct {
+		name   string
+		params []interface{}
+		want   string
+	}{
+		{"home", nil, "/"},
+		{"user.show", []interface{}{42}, "/v1/users/42"},
+		{"file", []interface{}{"css/a b.css"}, "/v1/files/css/a%20b.css"},
+		{"archive", nil, "/v1/archive"},
+		{"archive", []interface{}{2021, 8}, "/v1/archive/2021/8"},
+		{"search", []interface{}{"a/b?"}, "/search/a%2Fb%3F/"},
+	}
+	for _, tc := range cases {
+		got, err := r.URL(tc.name, tc.params...)
+		if err != nil || got != tc.want {
+			t.Errorf("URL(%s, %v) = %q %v, want %q", tc.name, tc.params, got, err, tc.want)
+		}
+	}
+
+	errorCases := []struct {
+		name   string
+		params []interface{}
+	}{
+		{"missing", nil},
+		{"user.show", nil},
+		{"user.show", []interface{}{"abc"}},
+		{"user.show", []interface{}{1, 2}},
+		{"search", []interface{}{""}},
+	}
+	for _, tc := range errorCases {
+		if got, err := r.URL(tc.name, tc.params...); err == nil {
+			t.Errorf("URL(%s, %v) should fail, got %q", tc.name, tc.params, got)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate route name should panic")
+		}
+	}()
+	r.GET("/other", func(c *Context) {}).Name("home")
+}
+
+func TestURLTemplateFunc(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := `{{define "user"}}<a href="{{ url "user.show" .ID }}">{{ upper .Name }}</a>{{end}}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "user.tmpl"), []byte(tmpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.SetFuncMap(map[string]interface{}{
+		"upper": func(s string) string { return s + "!" },
+	})
+	r.GET("/users/:id", func(c *Context) {
+		c.HTML(http.StatusOK, "user", H{"ID": c.Param("id"), "Name": "gee"})
+	}).Name("user.show")
+	r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+
+	w := performRequest(r, http.MethodGet, "/users/7")
+	if w.Body.String() != `<a href="/users/7">gee!</a>` {
+		t.Fatalf("unexpected body: %q", w.Body.String())
+	}
+}
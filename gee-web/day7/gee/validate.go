@@ -0,0 +1,234 @@
+package gee
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 结构体校验，规则写在 binding 标签中，以逗号分隔：
+//
+//	required    字段不能是零值
+//	min=N       数字不小于 N，字符串、切片、map 的长度不小于 N
+//	max=N       数字不大于 N，字符串、切片、map 的长度不大于 N
+//	len=N       字符串、切片、map 的长度等于 N
+//	oneof=a b c 值必须是列出的值之一
+//	omitempty   字段为零值时跳过其他规则，用于可选的字段，必须写在最前面，如 "omitempty,oneof=admin guest"
+//
+// 规则对零值同样生效，例如 min=1 不接受 0，oneof 不接受空字符串。嵌套的结构体会被递归校验。
+// 规则在结构体类型第一次校验时解析，未知的规则或错误的参数会直接 panic。
+
+// FieldError 描述一个未通过校验的字段
+type FieldError struct {
+	Field string // 字段路径，如 "Login.User"
+	Tag   string // 未通过的规则，如 "min"
+	Param string // 规则的参数，如 "3"
+}
+
+func (e *FieldError) Error() string {
+	if e.Param == "" {
+		return fmt.Sprintf("field '%s' failed on the '%s' rule", e.Field, e.Tag)
+	}
+	return fmt.Sprintf("field '%s' failed on the '%s=%s' rule", e.Field, e.Tag, e.Param)
+}
+
+// ValidationErrors 是一次校验中所有未通过的字段，每个字段只记录第一条未通过的规则
+type ValidationErrors []*FieldError
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, e := range ve {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func validate(obj interface{}) error {
+	rv := reflect.ValueOf(obj)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs ValidationErrors
+	validateStruct(rv, rv.Type().Name(), &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// fieldRules 是结构体中一个字段解析好的校验规则
+type fieldRules struct {
+	index     int
+	name      string
+	omitempty bool
+	rules     []rule
+	nested    bool // 字段是需要递归校验的结构体
+}
+
+type rule struct {
+	tag     string
+	param   string
+	num     float64  // min/max/len 的参数
+	options []string // oneof 的参数
+}
+
+// structRules 缓存每个结构体类型解析好的规则，类型第一次校验时解析，规则写错时立即 panic
+var structRules sync.Map // map[reflect.Type][]fieldRules
+
+func cachedRules(rt reflect.Type) []fieldRules {
+	if fields, ok := structRules.Load(rt); ok {
+		return fields.([]fieldRules)
+	}
+	fields := parseRules(rt)
+	structRules.Store(rt, fields)
+	return fields
+}
+
+func parseRules(rt reflect.Type) []fieldRules {
+	var fields []fieldRules
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tags := field.Tag.Get("binding")
+		if tags == "-" {
+			continue
+		}
+		f := fieldRules{index: i, name: field.Name, nested: isNestedStruct(field.Type)}
+		if tags != "" {
+			for _, s := range strings.Split(tags, ",") {
+				if s == "omitempty" && len(f.rules) == 0 {
+					f.omitempty = true
+					continue
+				}
+				f.rules = append(f.rules, parseRule(rt, field.Name, s))
+			}
+		}
+		// 结构体字段不会循环引用，可以直接解析以便尽早发现错误，指针在第一次校验时解析
+		if f.nested && field.Type.Kind() == reflect.Struct {
+			cachedRules(field.Type)
+		}
+		if f.nested || len(f.rules) > 0 || f.omitempty {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func parseRule(rt reflect.Type, field string, s string) rule {
+	r := rule{tag: s}
+	if idx := strings.IndexByte(s, '='); idx >= 0 {
+		r.tag, r.param = s[:idx], s[idx+1:]
+	}
+	invalid := func() {
+		panic(fmt.Sprintf("gee: invalid binding rule '%s' on field %s.%s", s, rt.Name(), field))
+	}
+	switch r.tag {
+	case "required":
+		if r.param != "" {
+			invalid()
+		}
+	case "min", "max", "len":
+		num, err := strconv.ParseFloat(r.param, 64)
+		if err != nil {
+			invalid()
+		}
+		r.num = num
+	case "oneof":
+		r.options = strings.Fields(r.param)
+		if len(r.options) == 0 {
+			invalid()
+		}
+	case "omitempty":
+		panic(fmt.Sprintf("gee: 'omitempty' must be the first binding rule on field %s.%s", rt.Name(), field))
+	default:
+		panic(fmt.Sprintf("gee: unknown binding rule '%s' on field %s.%s", r.tag, rt.Name(), field))
+	}
+	return r
+}
+
+func validateStruct(rv reflect.Value, namespace string, errs *ValidationErrors) {
+	for _, f := range cachedRules(rv.Type()) {
+		fv := rv.Field(f.index)
+		name := f.name
+		if namespace != "" {
+			name = namespace + "." + name
+		}
+		if !f.omitempty || !fv.IsZero() {
+			for _, r := range f.rules {
+				if !checkRule(fv, r) {
+					*errs = append(*errs, &FieldError{Field: name, Tag: r.tag, Param: r.param})
+					break
+				}
+			}
+		}
+		if f.nested {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			validateStruct(fv, name, errs)
+		}
+	}
+}
+
+func checkRule(fv reflect.Value, r rule) bool {
+	if r.tag == "required" {
+		return !fv.IsZero()
+	}
+	for fv.Kind() == reflect.Ptr {
+		// 没有值的指针不满足任何规则，可选的字段应使用 omitempty
+		if fv.IsNil() {
+			return false
+		}
+		fv = fv.Elem()
+	}
+	switch r.tag {
+	case "min":
+		return compareRule(fv, func(v float64) bool { return v >= r.num })
+	case "max":
+		return compareRule(fv, func(v float64) bool { return v <= r.num })
+	case "len":
+		return compareRule(fv, func(v float64) bool { return v == r.num })
+	default: // oneof
+		value := fmt.Sprint(fv.Interface())
+		for _, option := range r.options {
+			if value == option {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// compareRule 比较数字的值或字符串、切片、map 的长度
+func compareRule(fv reflect.Value, cmp func(v float64) bool) bool {
+	var v float64
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		v = fv.Float()
+	case reflect.String:
+		v = float64(utf8.RuneCountInString(fv.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		v = float64(fv.Len())
+	default:
+		return true
+	}
+	return cmp(v)
+}